@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"os"
 	"path"
-
-	"github.com/fxamacker/cbor/v2"
 )
 
 // DefaultConfig is default config
@@ -45,24 +43,45 @@ func Open(f string, cfg *Config) (*DB, error) {
 func (db *DB) Set(key any, value any) error {
 	db.Lock()
 	defer db.Unlock()
-	k, err := KeyToBinary(key)
+	k, err := db.keyToBinary(key)
 	if err != nil {
 		return err
 	}
-	v, err := ValToBinary(value)
+	v, err := db.valToBinary(value)
 	if err != nil {
 		return err
 	}
+	return db.set(k, v)
+}
+
+// set store binary key value without lock
+func (db *DB) set(k, v []byte) error {
+	return db.put(k, v, db.codec.ID(), 0, 0)
+}
 
+// put store binary key value encoded with codec, flags and expire time without lock
+func (db *DB) put(k, v []byte, codec uint8, flags, expire uint32) error {
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if db.indexed() {
+		return db.commit([]*txRecord{{t: recSet, key: k, val: bytes.Clone(v), codec: codec, flags: flags, expire: expire}})
+	}
 	oldCmd, exists := db.vals[string(k)]
+	rev := db.nextRev()
 	if db.storemode == 2 {
 		cmd := &Cmd{}
 		cmd.Size = uint32(len(v))
 		cmd.Val = make([]byte, len(v))
+		cmd.Rev = rev
+		cmd.codec = codec
+		cmd.flags = flags
+		cmd.expire = expire
 		copy(cmd.Val, v)
 		db.vals[string(k)] = cmd
 	} else {
-		cmd, err := writeKeyVal(db.fk, db.fv, k, v, exists, oldCmd)
+		t, rk := db.rec(recSet, k)
+		cmd, err := writeKeyVal(db.fk, db.fv, t, rk, v, exists, db.snapshots == 0, oldCmd, rev, codec, flags, expire)
 		if err != nil {
 			return err
 		}
@@ -71,8 +90,9 @@ func (db *DB) Set(key any, value any) error {
 	if !exists {
 		db.appendKey(k)
 	}
+	db.notify(EventSet, k, v, rev)
 
-	return err
+	return db.logChange(recSet, k, v, codec, flags, expire, rev)
 }
 
 // Get return value by key
@@ -80,50 +100,44 @@ func (db *DB) Set(key any, value any) error {
 func (db *DB) Get(key any, value any) error {
 	db.RLock()
 	defer db.RUnlock()
-	k, err := KeyToBinary(key)
+	k, err := db.keyToBinary(key)
 	if err != nil {
 		return err
 	}
-	if val, ok := db.vals[string(k)]; ok {
-		switch value := value.(type) {
-		case *[]byte:
-			{
-				b := make([]byte, val.Size)
-				if db.storemode == 2 {
-					copy(b, val.Val)
-				} else {
-					_, err := db.fv.ReadAt(b, int64(val.Seek))
-					if err != nil {
-						return err
-					}
-				}
-
-				*value = b
-				return nil
-			}
-		default:
-			{
-				b := make([]byte, val.Size)
-				if db.storemode == 2 {
-					copy(b, val.Val)
-				} else {
-					_, err := db.fv.ReadAt(b, int64(val.Seek))
-					if err != nil {
-						return err
-					}
-				}
+	return db.get(k, value)
+}
 
-				return cbor.Unmarshal(b, value)
-			}
-		}
+// get return value by binary key without lock
+func (db *DB) get(k []byte, value any) error {
+	val, ok := db.live(k)
+	if !ok {
+		return ErrKeyNotFound
 	}
+	b, err := db.readVal(val)
+	if err != nil {
+		return err
+	}
+	return db.unmarshal(val.codec, b, value)
+}
 
-	return ErrKeyNotFound
+// readVal return copy of stored value
+func (db *DB) readVal(val *Cmd) ([]byte, error) {
+	b := make([]byte, val.Size)
+	if db.storemode == 2 {
+		copy(b, val.Val)
+		return b, nil
+	}
+	_, err := db.fv.ReadAt(b, int64(val.Seek))
+	return b, err
 }
 
 // Close - sync & close files.
 // Return error if any.
+// Close of bucket do nothing, bucket is closed with db.
 func (db *DB) Close() error {
+	if db.parent != nil {
+		return nil
+	}
 	if db.cancelSyncer != nil {
 		db.cancelSyncer()
 	}
@@ -131,16 +145,19 @@ func (db *DB) Close() error {
 	defer db.Unlock()
 
 	if db.storemode == 2 && db.name != "" {
-		db.sort()
-		keys := make([][]byte, len(db.keys))
-
-		copy(keys, db.keys)
-
-		db.storemode = 0
-		for _, k := range keys {
-			if val, ok := db.vals[string(k)]; ok {
-				writeKeyVal(db.fk, db.fv, k, val.Val, false, nil)
-			}
+		writeKey(db.fk, recKeyEncoding, 0, uint32(db.keyEncoding), 0, 0, 0, 0, nil, -1)
+		db.persist()
+		for name, b := range db.buckets {
+			writeKey(db.fk, recBucket, 0, 0, 0, 0, 0, 0, []byte(name), -1)
+			b.persist()
+		}
+	}
+	for w := range db.watchers {
+		db.unwatch(w)
+	}
+	for _, b := range db.buckets {
+		for w := range b.watchers {
+			b.unwatch(w)
 		}
 	}
 	if db.fk != nil {
@@ -153,6 +170,12 @@ func (db *DB) Close() error {
 			return err
 		}
 	}
+	if db.fl != nil {
+		err := db.fl.Close()
+		if err != nil {
+			return err
+		}
+	}
 	if db.fv != nil {
 		err := db.fv.Sync()
 		if err != nil {
@@ -171,6 +194,28 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// persist write values and index entries of memory db to files
+func (db *DB) persist() {
+	db.sort()
+	keys := make([][]byte, len(db.keys))
+
+	copy(keys, db.keys)
+
+	db.storemode = 0
+	for _, k := range keys {
+		if val, ok := db.vals[string(k)]; ok {
+			t, rk := db.rec(recSet, k)
+			writeKeyVal(db.fk, db.fv, t, rk, val.Val, false, false, nil, val.Rev, val.codec, val.flags, val.expire)
+		}
+	}
+	for _, ix := range db.indexes {
+		for e := range ix.entries {
+			t, rk := db.rec(recIndex, []byte(e))
+			writeKey(db.fk, t, 0, 0, 0, 0, 0, 0, rk, -1)
+		}
+	}
+}
+
 // CloseAll - close all opened Db
 func CloseAll() (err error) {
 	dbs.Lock()
@@ -187,7 +232,11 @@ func CloseAll() (err error) {
 }
 
 // DeleteFile close and delete file
+// DeleteFile of bucket delete bucket
 func (db *DB) DeleteFile() error {
+	if db.parent != nil {
+		return db.parent.DeleteBucket(string(db.bucket[1:]))
+	}
 	return DeleteFile(db.name)
 }
 
@@ -213,6 +262,13 @@ func DeleteFile(file string) error {
 		return err
 	}
 	err = os.Remove(file + ".idx")
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file + ".log")
+	if os.IsNotExist(err) {
+		return nil
+	}
 	return err
 }
 
@@ -221,11 +277,11 @@ func DeleteFile(file string) error {
 func (db *DB) Has(key any) (bool, error) {
 	db.RLock()
 	defer db.RUnlock()
-	k, err := KeyToBinary(key)
+	k, err := db.keyToBinary(key)
 	if err != nil {
 		return false, err
 	}
-	_, has := db.vals[string(k)]
+	_, has := db.live(k)
 	return has, nil
 }
 
@@ -257,15 +313,34 @@ func (db *DB) Count() (int, error) {
 func (db *DB) Delete(key any) error {
 	db.Lock()
 	defer db.Unlock()
-	k, err := KeyToBinary(key)
+	k, err := db.keyToBinary(key)
 	if err != nil {
 		return err
 	}
+	return db.del(k)
+}
+
+// del remove binary key without lock
+func (db *DB) del(k []byte) error {
+	return db.remove(k, EventDelete)
+}
+
+// remove binary key and notify watchers with event t without lock
+func (db *DB) remove(k []byte, e EventType) error {
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if _, ok := db.vals[string(k)]; ok && db.indexed() {
+		return db.commit([]*txRecord{{t: recDelete, key: k, expired: e == EventExpire}})
+	}
 	if _, ok := db.vals[string(k)]; ok {
 		delete(db.vals, string(k))
 		db.deleteFromKeys(k)
-		writeKey(db.fk, 1, 0, 0, k, -1)
-		return nil
+		t, rk := db.rec(recDelete, k)
+		rev := db.nextRev()
+		writeKey(db.fk, t, 0, 0, rev, 0, 0, 0, rk, -1)
+		db.notify(e, k, nil, rev)
+		return db.logChange(recDelete, k, nil, 0, 0, 0, rev)
 	}
 	return ErrKeyNotFound
 }
@@ -323,7 +398,7 @@ func (db *DB) Keys(from any, limit, offset int, asc bool) ([][]byte, error) {
 	if from != nil {
 		excludeFrom = 1
 
-		k, err := KeyToBinary(from)
+		k, err := db.keyToBinary(from)
 		if err != nil {
 			return arr, err
 		}
@@ -374,7 +449,7 @@ func Set(f string, key any, value any) error {
 	return db.Set(key, value)
 }
 
-// Sets store vals and keys
+// Sets store vals and keys atomically
 // Use it for mass insertion
 // every pair must contain key and value
 func Sets(file string, pairs []any) (err error) {
@@ -383,20 +458,17 @@ func Sets(file string, pairs []any) (err error) {
 		return err
 	}
 
+	b := new(Batch)
 	for i := range pairs {
 		if i%2 != 0 {
 			if pairs[i] == nil || pairs[i-1] == nil {
 				continue
 			}
-
-			err = db.Set(pairs[i-1], pairs[i])
-			if err != nil {
-				break
-			}
+			b.Put(pairs[i-1], pairs[i])
 		}
 	}
 
-	return err
+	return db.Write(b)
 }
 
 // Get return value by key with opening if needed
@@ -424,7 +496,7 @@ func Gets(file string, keys []any) (result [][]byte) {
 		var v []byte
 		err := db.Get(key, &v)
 		if err == nil {
-			k, err := KeyToBinary(key)
+			k, err := db.keyToBinary(key)
 			if err == nil {
 				val, err := ValToBinary(v)
 				if err == nil {
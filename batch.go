@@ -0,0 +1,102 @@
+package fudge
+
+import (
+	"bytes"
+)
+
+// recDeletePrefix is batch only operation, never stored in index
+const recDeletePrefix uint8 = 0xff
+
+// Batch collect Put/Delete/DeletePrefix operations for atomic db.Write.
+// Keys and values are converted to binary form by db.Write.
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	t     uint8
+	key   any
+	value any
+}
+
+// Put store key value on batch write
+func (b *Batch) Put(key any, value any) {
+	b.ops = append(b.ops, batchOp{t: recSet, key: key, value: value})
+}
+
+// Delete remove key on batch write.
+// Not existing keys are ignored.
+func (b *Batch) Delete(key any) {
+	b.ops = append(b.ops, batchOp{t: recDelete, key: key})
+}
+
+// DeletePrefix remove all keys with prefix on batch write,
+// including keys put in batch before.
+func (b *Batch) DeletePrefix(prefix []byte) {
+	b.ops = append(b.ops, batchOp{t: recDeletePrefix, key: bytes.Clone(prefix)})
+}
+
+// Len return count of operations in batch
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Reset clear batch for reuse
+func (b *Batch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// Write apply batch atomically.
+// Values and one frame of index records are written and synced with one
+// parallel fsync of value and index files, without barrier between them:
+// commit record keep crc32 of values, frame with torn values is dropped on open.
+// On error nothing is applied.
+func (db *DB) Write(b *Batch) error {
+	return db.Update(func(tx *Tx) error {
+		for _, op := range b.ops {
+			switch op.t {
+			case recSet:
+				k, v, err := db.keyVal(op.key, op.value)
+				if err != nil {
+					return err
+				}
+				tx.put(recSet, k, bytes.Clone(v))
+			case recDelete:
+				k, err := db.keyToBinary(op.key)
+				if err != nil {
+					return err
+				}
+				tx.put(recDelete, k, nil)
+			case recDeletePrefix:
+				tx.deletePrefix(op.key.([]byte))
+			}
+		}
+		return nil
+	})
+}
+
+// DeletePrefix remove all keys with prefix in transaction
+func (tx *Tx) DeletePrefix(prefix []byte) error {
+	if tx.db == nil {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	tx.deletePrefix(prefix)
+	return nil
+}
+
+func (tx *Tx) deletePrefix(prefix []byte) {
+	db := tx.db
+	db.sort()
+	for i := db.prefixFirst(prefix); i < len(db.keys) && startFrom(db.keys[i], prefix); i++ {
+		tx.put(recDelete, db.keys[i], nil)
+	}
+	for _, r := range tx.ops {
+		if r.t == recSet && startFrom(r.key, prefix) {
+			r.t = recDelete
+			r.val = nil
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fudge
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	f := "test/batch"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("user:1", 1)
+	db.Set("user:2", 2)
+	db.Set("other", 0)
+
+	b := new(Batch)
+	for i := range 10 {
+		b.Put(fmt.Sprintf("item:%d", i), i)
+	}
+	b.Put("user:3", 3)
+	b.DeletePrefix([]byte("user:"))
+	b.Put("user:4", 4)
+	b.Delete("other")
+	b.Delete("missing")
+	if b.Len() != 15 {
+		t.Error("batch len must be 15", b.Len())
+	}
+	err = db.Write(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func() {
+		keys, _ := db.Keys("user:*", 0, 0, true)
+		if len(keys) != 1 || string(keys[0]) != "user:4" {
+			t.Error("user:4 must be only user", keys)
+		}
+		if has, _ := db.Has("other"); has {
+			t.Error("other must be deleted")
+		}
+		if cnt, _ := db.Count(); cnt != 11 {
+			t.Error("count must be 11", cnt)
+		}
+		var v int
+		db.Get("item:7", &v)
+		if v != 7 {
+			t.Error("item:7 must be 7", v)
+		}
+	}
+	check()
+	db.Close()
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check()
+
+	b.Reset()
+	b.Put("ok", 1)
+	b.Put(make(chan int), 1)
+	if err = db.Write(b); err == nil {
+		t.Error("batch with bad key must fail")
+	}
+	if has, _ := db.Has("ok"); has {
+		t.Error("failed batch must not be applied")
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatchTornValues(t *testing.T) {
+	f := "test/batch_torn"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(Batch)
+	b.Put("a", []byte("first"))
+	db.Write(b)
+	b.Reset()
+	b.Put("b", []byte("second"))
+	b.Put("c", []byte("third"))
+	db.Write(b)
+	db.Close()
+
+	// values of last batch are not on disk after crash
+	st, _ := os.Stat(f)
+	os.Truncate(f, st.Size()-2)
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	if has, _ := db.Has("b"); has {
+		t.Error("torn batch must be dropped")
+	}
+	var v []byte
+	if err = db.Get("a", &v); err != nil || string(v) != "first" {
+		t.Error("first batch must stay", string(v), err)
+	}
+	db.Set("d", []byte("after"))
+	if n, _ := db.Count(); n != 2 {
+		t.Error("must be 2 keys", n)
+	}
+}
@@ -0,0 +1,162 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// ErrBucketName - bucket name is empty or longer than 255 bytes
+var ErrBucketName = errors.New("error: bad bucket name")
+
+// Bucket return db of bucket with isolated keyspace, create bucket if not exists.
+// Bucket is stored in files of db and share its lock, config and revisions,
+// Close of bucket do nothing, DeleteFile of bucket delete bucket.
+//
+//	users, _ := db.Bucket("users")
+//	users.Set(1, user)
+func (db *DB) Bucket(name string) (*DB, error) {
+	if db.parent != nil {
+		return db.parent.Bucket(name)
+	}
+	if name == "" || len(name) > 255 {
+		return nil, ErrBucketName
+	}
+	db.Lock()
+	defer db.Unlock()
+	return db.createBucket(name)
+}
+
+// createBucket return bucket, create and store it if not exists, without lock
+func (db *DB) createBucket(name string) (*DB, error) {
+	if b, ok := db.buckets[name]; ok {
+		return b, nil
+	}
+	if err := db.writable(); err != nil {
+		return nil, err
+	}
+	if db.storemode != 2 {
+		_, err := writeKey(db.fk, recBucket, 0, 0, 0, 0, 0, 0, []byte(name), -1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return db.newBucket(name), nil
+}
+
+// DeleteBucket delete bucket with all keys.
+// Bucket db returned before must not be used after delete.
+func (db *DB) DeleteBucket(name string) error {
+	if db.parent != nil {
+		return db.parent.DeleteBucket(name)
+	}
+	db.Lock()
+	defer db.Unlock()
+	if err := db.writable(); err != nil {
+		return err
+	}
+	return db.deleteBucket(name)
+}
+
+// deleteBucket delete bucket without lock
+func (db *DB) deleteBucket(name string) error {
+	if _, ok := db.buckets[name]; !ok {
+		return ErrKeyNotFound
+	}
+	rev := db.nextRev()
+	_, err := writeKey(db.fk, recDropBucket, 0, 0, rev, 0, 0, 0, []byte(name), -1)
+	if err != nil && db.storemode != 2 {
+		return err
+	}
+	db.dropBucket(name)
+	if db.fl != nil {
+		return db.writeLog(recDropBucket, []byte(name), nil, 0, 0, 0, rev)
+	}
+	return nil
+}
+
+// ListBuckets return names of buckets in ascending order
+func (db *DB) ListBuckets() []string {
+	if db.parent != nil {
+		return db.parent.ListBuckets()
+	}
+	db.RLock()
+	defer db.RUnlock()
+	names := make([]string, 0, len(db.buckets))
+	for name := range db.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newBucket return bucket, create it if not exists
+func (db *DB) newBucket(name string) *DB {
+	if b, ok := db.buckets[name]; ok {
+		return b
+	}
+	b := &DB{
+		RWMutex:     db.RWMutex,
+		name:        db.name,
+		fk:          db.fk,
+		fv:          db.fv,
+		keys:        make([][]byte, 0),
+		vals:        make(map[string]*Cmd),
+		storemode:   db.storemode,
+		merge:       db.merge,
+		keyEncoding: db.keyEncoding,
+		cmp:         db.cmp,
+		codec:       db.codec,
+		indexes:     make(map[string]*index),
+		parent:      db,
+		bucket:      append([]byte{byte(len(name))}, name...),
+	}
+	db.buckets[name] = b
+	return b
+}
+
+// dropBucket remove bucket from db, bucket become empty
+func (db *DB) dropBucket(name string) {
+	b, ok := db.buckets[name]
+	if !ok {
+		return
+	}
+	b.keys = make([][]byte, 0)
+	b.vals = make(map[string]*Cmd)
+	b.indexes = make(map[string]*index)
+	for w := range b.watchers {
+		b.unwatch(w)
+	}
+	delete(db.buckets, name)
+}
+
+// rec return type and key of index record for key of db
+func (db *DB) rec(t uint8, k []byte) (uint8, []byte) {
+	if db.bucket == nil {
+		return t, k
+	}
+	return t | recInBucket, append(bytes.Clone(db.bucket), k...)
+}
+
+// root return db of bucket or db itself
+func (db *DB) root() *DB {
+	if db.parent != nil {
+		return db.parent
+	}
+	return db
+}
+
+// writable return ErrReadOnly if db opened with ReadOnly
+func (db *DB) writable() error {
+	if r := db.root(); r.readonly && !r.replicating {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// nextRev increment revision of db, buckets share revisions with db
+func (db *DB) nextRev() uint64 {
+	r := db.root()
+	r.rev++
+	return r.rev
+}
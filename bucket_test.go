@@ -0,0 +1,86 @@
+package fudge
+
+import (
+	"testing"
+)
+
+func TestBucket(t *testing.T) {
+	f := "test/bucket"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set(1, "root")
+	users, err := db.Bucket("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, _ := db.Bucket("orders")
+	users.Set(1, "alice")
+	users.Set(2, "bob")
+	users.Set(2, "bobby")
+	orders.Set(1, "order")
+	orders.Update(func(tx *Tx) error {
+		tx.Set(2, "order2")
+		return tx.Delete(1)
+	})
+	db.Bucket("empty")
+	if _, err = db.Bucket(""); err != ErrBucketName {
+		t.Error("must be ErrBucketName", err)
+	}
+	var s string
+	db.Get(1, &s)
+	if s != "root" {
+		t.Error("root value must be isolated", s)
+	}
+	if c, _ := db.Count(); c != 1 {
+		t.Error("root count must be 1", c)
+	}
+	db.Close()
+
+	db, err = Open(f, &Config{StoreMode: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := db.ListBuckets()
+	if len(names) != 3 || names[0] != "empty" || names[2] != "users" {
+		t.Error("bad buckets", names)
+	}
+	users, _ = db.Bucket("users")
+	users.Get(2, &s)
+	if s != "bobby" {
+		t.Error("must be bobby", s)
+	}
+	if c, _ := users.Count(); c != 2 {
+		t.Error("users count must be 2", c)
+	}
+	orders, _ = db.Bucket("orders")
+	keys, _ := orders.Keys(nil, 0, 0, true)
+	if len(keys) != 1 {
+		t.Error("orders must have 1 key", keys)
+	}
+	users.Set(3, "carol")
+	err = db.DeleteBucket("orders")
+	if err != nil {
+		t.Error(err)
+	}
+	db.Close()
+
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = db.ListBuckets()
+	if len(names) != 2 || names[1] != "users" {
+		t.Error("bad buckets after delete", names)
+	}
+	users, _ = db.Bucket("users")
+	if c, _ := users.Count(); c != 3 {
+		t.Error("users count must be 3", c)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
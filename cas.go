@@ -0,0 +1,132 @@
+package fudge
+
+import (
+	"bytes"
+	"time"
+)
+
+// KeyStat represent key metadata
+type KeyStat struct {
+	Size     uint32    // value size in bytes
+	Revision uint64    // db revision of last change, grows on every change
+	Flags    uint32    // flags of value, see SetWith
+	Expire   time.Time // expiration time, zero - never
+}
+
+// Stat return key metadata.
+// Revision may be used for optimistic concurrency control:
+// if revision not changed - value not changed.
+func (db *DB) Stat(key any) (*KeyStat, error) {
+	db.RLock()
+	defer db.RUnlock()
+	k, err := db.keyToBinary(key)
+	if err != nil {
+		return nil, err
+	}
+	val, ok := db.live(k)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return val.stat(), nil
+}
+
+// Revision return last revision of db
+func (db *DB) Revision() uint64 {
+	db.RLock()
+	defer db.RUnlock()
+	return db.root().rev
+}
+
+// CompareAndSwap store new value only if current value equal old.
+// Values are compared in binary form.
+// Returns ErrKeyNotFound if key not found
+func (db *DB) CompareAndSwap(key, old, new any) (bool, error) {
+	k, o, err := db.keyVal(key, old)
+	if err != nil {
+		return false, err
+	}
+	v, err := db.valToBinary(new)
+	if err != nil {
+		return false, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	equal, err := db.equal(k, o)
+	if !equal || err != nil {
+		return false, err
+	}
+	return true, db.set(k, v)
+}
+
+// SetIfNotExists store key value only if key not exists
+func (db *DB) SetIfNotExists(key, value any) (bool, error) {
+	k, v, err := db.keyVal(key, value)
+	if err != nil {
+		return false, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	if _, ok := db.live(k); ok {
+		return false, nil
+	}
+	return true, db.set(k, v)
+}
+
+// SetIfExists store key value only if key exists
+func (db *DB) SetIfExists(key, value any) (bool, error) {
+	k, v, err := db.keyVal(key, value)
+	if err != nil {
+		return false, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	if _, ok := db.live(k); !ok {
+		return false, nil
+	}
+	return true, db.set(k, v)
+}
+
+// DeleteIf remove key only if current value equal expected.
+// Returns ErrKeyNotFound if key not found
+func (db *DB) DeleteIf(key, expected any) (bool, error) {
+	k, e, err := db.keyVal(key, expected)
+	if err != nil {
+		return false, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	equal, err := db.equal(k, e)
+	if !equal || err != nil {
+		return false, err
+	}
+	return true, db.del(k)
+}
+
+// equal compare stored value with b without lock
+func (db *DB) equal(k, b []byte) (bool, error) {
+	val, ok := db.live(k)
+	if !ok {
+		return false, ErrKeyNotFound
+	}
+	if val.Size != uint32(len(b)) {
+		return false, nil
+	}
+	cur, err := db.readVal(val)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(cur, b), nil
+}
+
+// keyVal convert key and value to binary
+func (db *DB) keyVal(key, value any) ([]byte, []byte, error) {
+	k, err := db.keyToBinary(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := db.valToBinary(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, v, nil
+}
@@ -0,0 +1,138 @@
+package fudge
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	f := "test/cas"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("counter", 0)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for {
+					var v int
+					db.Get("counter", &v)
+					ok, err := db.CompareAndSwap("counter", v, v+1)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	var v int
+	db.Get("counter", &v)
+	if v != 100 {
+		t.Error("counter must be 100", v)
+	}
+	if _, err = db.CompareAndSwap("missing", 1, 2); err != ErrKeyNotFound {
+		t.Error("must be ErrKeyNotFound", err)
+	}
+
+	ok, _ := db.SetIfNotExists("counter", 1)
+	if ok {
+		t.Error("counter exists")
+	}
+	ok, _ = db.SetIfExists("new", 1)
+	if ok {
+		t.Error("new not exists")
+	}
+	ok, _ = db.SetIfNotExists("new", 1)
+	if !ok {
+		t.Error("new must be stored")
+	}
+	ok, _ = db.SetIfExists("new", 2)
+	if !ok {
+		t.Error("new must be updated")
+	}
+	ok, _ = db.DeleteIf("new", 1)
+	if ok {
+		t.Error("new is not 1")
+	}
+	ok, _ = db.DeleteIf("new", 2)
+	if !ok {
+		t.Error("new must be deleted")
+	}
+
+	st1, _ := db.Stat("counter")
+	db.Set("counter", 101)
+	st2, _ := db.Stat("counter")
+	if st2.Revision <= st1.Revision {
+		t.Error("revision must grow", st1.Revision, st2.Revision)
+	}
+	rev := db.Revision()
+	db.Close()
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st3, _ := db.Stat("counter")
+	if st3.Revision != st2.Revision || db.Revision() != rev {
+		t.Error("revision must persist", st2.Revision, st3.Revision, rev, db.Revision())
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLegacyIndex(t *testing.T) {
+	f := "test/legacy"
+	os.MkdirAll("test", 0755)
+	// value file and version 0 index record for key "k"
+	os.WriteFile(f, []byte{1}, 0644)
+	rec := make([]byte, 17)
+	binary.BigEndian.PutUint32(rec[6:10], 1) // size
+	binary.BigEndian.PutUint16(rec[14:16], 1)
+	rec[16] = 'k'
+	os.WriteFile(f+".idx", rec, 0644)
+
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	db.Get("k", &v)
+	if v != 1 {
+		t.Error("k must be 1", v)
+	}
+	st, _ := db.Stat("k")
+	if st.Revision != 1 {
+		t.Error("revision must be 1", st.Revision)
+	}
+	// version 1 record appended, version 0 record not overwritten
+	db.Set("k", 2)
+	db.Close()
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Get("k", &v)
+	if v != 2 {
+		t.Error("k must be 2", v)
+	}
+	if cnt, _ := db.Count(); cnt != 1 {
+		t.Error("count must be 1", cnt)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,318 @@
+package fudge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNoChangeLog - db opened without Config.ChangeLog
+	ErrNoChangeLog = errors.New("error: change log disabled")
+	// ErrChangesTrimmed - changes after seq are removed from change log
+	ErrChangesTrimmed = errors.New("error: changes trimmed")
+)
+
+// Change is one mutation from change log
+type Change struct {
+	Seq    uint64    // sequence number, revision of change
+	Type   EventType // EventSet, EventDelete or EventDropBucket
+	Bucket string    // name of bucket, "" for db
+	Key    []byte
+	Value  []byte    // new value in binary form for EventSet
+	Flags  uint32    // flags of value for EventSet
+	Expire time.Time // expiration of value for EventSet, zero - never
+}
+
+// ChangesSince return iterator over changes with sequence number greater than seq,
+// in order of sequence numbers. Save Seq of last processed change and resume from it:
+//
+//	for c, err := range db.ChangesSince(last) {
+//		if err != nil {
+//			return err
+//		}
+//		process(c)
+//		last = c.Seq
+//	}
+//
+// Change log is stored in file.log if db opened with Config.ChangeLog,
+// use TrimChanges to remove old changes. Changes of bucket db are changes of bucket only.
+func (db *DB) ChangesSince(seq uint64) iter.Seq2[Change, error] {
+	return func(yield func(Change, error) bool) {
+		root := db.root()
+		root.RLock()
+		if root.fl == nil {
+			root.RUnlock()
+			yield(Change{}, ErrNoChangeLog)
+			return
+		}
+		st, err := root.fl.Stat()
+		root.RUnlock()
+		if err != nil {
+			yield(Change{}, err)
+			return
+		}
+		f, err := os.Open(root.name + ".log")
+		if err != nil {
+			yield(Change{}, err)
+			return
+		}
+		defer f.Close()
+		stop := false
+		_, err = scanChanges(f, 0, st.Size(), seq, func(c Change) bool {
+			if db.parent != nil && c.Bucket != string(db.bucket[1:]) {
+				return true
+			}
+			stop = !yield(c, nil)
+			return !stop
+		})
+		if err != nil && !stop {
+			yield(Change{}, err)
+		}
+	}
+}
+
+// scanChanges call fn for changes after seq in change log from offset off up to size
+// and return offset after last read record
+func scanChanges(f *os.File, off, size int64, seq uint64, fn func(c Change) bool) (int64, error) {
+	var err error
+	off, rerr := readLog(f, off, size, func(t uint8, key []byte, cmd *Cmd, val []byte) bool {
+		if t == recBegin {
+			if seq < cmd.Rev {
+				err = ErrChangesTrimmed
+				return false
+			}
+			return true
+		}
+		if cmd.Rev <= seq {
+			return true
+		}
+		return fn(toChange(t, key, cmd, val))
+	})
+	if rerr != nil {
+		return off, rerr
+	}
+	return off, err
+}
+
+// toChange return change of change log record
+func toChange(t uint8, key []byte, cmd *Cmd, val []byte) Change {
+	c := Change{Seq: cmd.Rev, Type: EventSet, Key: key, Value: val, Flags: cmd.flags, Expire: cmd.expireTime()}
+	if t == recDropBucket {
+		return Change{Seq: cmd.Rev, Type: EventDropBucket, Bucket: string(key)}
+	}
+	if t&recInBucket != 0 {
+		c.Bucket = string(key[1 : 1+key[0]])
+		c.Key = key[1+key[0]:]
+	}
+	if t&^recInBucket == recDelete {
+		c.Type = EventDelete
+		c.Value = nil
+	}
+	return c
+}
+
+// TrimChanges remove changes with sequence number less or equal seq from change log
+func (db *DB) TrimChanges(seq uint64) error {
+	root := db.root()
+	root.Lock()
+	defer root.Unlock()
+	if root.fl == nil {
+		return ErrNoChangeLog
+	}
+	st, err := root.fl.Stat()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.Create(root.name + ".log.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	buf := new(bytes.Buffer)
+	var werr error
+	_, err = readLog(root.fl, 0, st.Size(), func(t uint8, key []byte, cmd *Cmd, val []byte) bool {
+		buf.Reset()
+		switch {
+		case t == recBegin:
+			encodeKey(buf, recBegin, 0, 0, max(seq, cmd.Rev), 0, 0, 0, nil)
+		case cmd.Rev > seq:
+			encodeKey(buf, t, 0, cmd.Size, cmd.Rev, cmd.codec, cmd.flags, cmd.expire, key)
+			buf.Write(val)
+		}
+		_, werr = w.Write(buf.Bytes())
+		return werr == nil
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), root.name+".log")
+	if err != nil {
+		return err
+	}
+	root.fl.Close()
+	root.fl, err = os.OpenFile(root.name+".log", os.O_RDWR, 0)
+	root.logGen++
+	return err
+}
+
+// openLog open change log and return last logged sequence number,
+// fresh is true for new change log, db must be locked
+func (db *DB) openLog(mode os.FileMode) (seq uint64, fresh bool, err error) {
+	db.fl, err = os.OpenFile(db.name+".log", os.O_CREATE|os.O_RDWR, mode)
+	if err != nil {
+		return 0, false, err
+	}
+	st, err := db.fl.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	size, err := readLog(db.fl, 0, st.Size(), func(t uint8, key []byte, cmd *Cmd, val []byte) bool {
+		seq = max(seq, cmd.Rev)
+		return true
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if size < st.Size() {
+		// torn record at the end of file
+		err = db.fl.Truncate(size)
+	}
+	return seq, size == 0, err
+}
+
+// recoverLog append to change log records missed by crash, db must be locked
+func (db *DB) recoverLog(recs []*txRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].cmd.Rev < recs[j].cmd.Rev
+	})
+	for _, r := range recs {
+		var val []byte
+		if r.t&^recInBucket == recSet {
+			val = make([]byte, r.cmd.Size)
+			_, err := db.fv.ReadAt(val, int64(r.cmd.Seek))
+			if err != nil {
+				return err
+			}
+		}
+		err := db.writeLog(r.t, r.key, val, r.cmd.codec, r.cmd.flags, r.cmd.expire, r.cmd.Rev)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logChange append change of db or bucket to change log, db must be locked
+func (db *DB) logChange(t uint8, k, v []byte, codec uint8, flags, expire uint32, rev uint64) error {
+	root := db.root()
+	if root.fl == nil {
+		return nil
+	}
+	t, k = db.rec(t, k)
+	return root.writeLog(t, k, v, codec, flags, expire, rev)
+}
+
+// writeLog append record with value to change log
+func (db *DB) writeLog(t uint8, k, v []byte, codec uint8, flags, expire uint32, rev uint64) error {
+	buf := new(bytes.Buffer)
+	encodeKey(buf, t, 0, uint32(len(v)), rev, codec, flags, expire, k)
+	buf.Write(v)
+	_, _, err := writeAtPos(db.fl, buf.Bytes(), -1)
+	if db.logSignal != nil {
+		close(db.logSignal)
+		db.logSignal = nil
+	}
+	return err
+}
+
+// logWait return channel closed on next write to change log
+func (db *DB) logWait() <-chan struct{} {
+	db.Lock()
+	defer db.Unlock()
+	if db.logSignal == nil {
+		db.logSignal = make(chan struct{})
+	}
+	return db.logSignal
+}
+
+// readLog call fn for every full record of change log from offset off up to size
+// and return offset after last read record.
+// Record with type recBegin mark that changes before its revision are trimmed.
+func readLog(f *os.File, off, size int64, fn func(t uint8, key []byte, cmd *Cmd, val []byte) bool) (int64, error) {
+	// buffer must hold header with the longest key for Peek in readRecord
+	r := bufio.NewReaderSize(io.NewSectionReader(f, off, size-off), headerSize(recVersion)+math.MaxUint16)
+	for {
+		t, key, cmd, val, n, err := readRecord(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// end of file or torn record
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+		off += int64(n)
+		if !fn(t, key, cmd, val) {
+			return off, nil
+		}
+	}
+}
+
+// readRecord read record with value from r,
+// return size of record with value
+func readRecord(r *bufio.Reader) (t uint8, key []byte, cmd *Cmd, val []byte, n int, err error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return
+	}
+	hs := headerSize(b[0])
+	if hs == 0 {
+		err = ErrFormat
+		return
+	}
+	if b, err = r.Peek(hs); err != nil {
+		return
+	}
+	if b, err = r.Peek(hs + int(binary.BigEndian.Uint16(b[hs-2:hs]))); err != nil {
+		return
+	}
+	t, key, cmd, n, err = decodeKey(b)
+	if err != nil {
+		return
+	}
+	key = bytes.Clone(key)
+	_, _ = r.Discard(n)
+	val = make([]byte, cmd.Size)
+	if _, err = io.ReadFull(r, val); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	return t, key, cmd, val, n + len(val), nil
+}
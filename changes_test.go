@@ -0,0 +1,129 @@
+package fudge
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChangesSince(t *testing.T) {
+	f := "test/changes"
+	DeleteFile(f)
+	db, err := Open(f, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("a", []byte("1"))
+	db.Set("b", []byte("2"))
+	db.Delete("a")
+	db.Update(func(tx *Tx) error {
+		tx.Set("c", []byte("3"))
+		return tx.Set("d", []byte("4"))
+	})
+	users, _ := db.Bucket("users")
+	users.Set("u", []byte("5"))
+
+	changes := make([]Change, 0)
+	for c, err := range db.ChangesSince(0) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) != 6 {
+		t.Fatal("must be 6 changes", changes)
+	}
+	if changes[2].Type != EventDelete || string(changes[2].Key) != "a" {
+		t.Error("bad delete", changes[2])
+	}
+	if changes[5].Bucket != "users" || string(changes[5].Value) != "5" {
+		t.Error("bad bucket change", changes[5])
+	}
+	for i := 1; i < len(changes); i++ {
+		if changes[i].Seq <= changes[i-1].Seq {
+			t.Error("seq must grow", changes)
+		}
+	}
+	last := changes[3].Seq
+	n := 0
+	for c := range users.ChangesSince(0) {
+		if c.Bucket != "users" {
+			t.Error("bucket must see own changes", c)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Error("must be 1 bucket change", n)
+	}
+	db.Close()
+
+	// lose last change of log as after crash
+	st, _ := os.Stat(f + ".log")
+	os.Truncate(f+".log", st.Size()-3)
+	db, err = Open(f, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("e", []byte("6"))
+	changes = changes[:0]
+	for c := range db.ChangesSince(last) {
+		changes = append(changes, c)
+	}
+	if len(changes) != 3 || changes[1].Bucket != "users" || string(changes[2].Key) != "e" {
+		t.Error("bad changes after reopen", changes)
+	}
+
+	err = db.TrimChanges(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err = range db.ChangesSince(1) {
+		break
+	}
+	if err != ErrChangesTrimmed {
+		t.Error("must be ErrChangesTrimmed", err)
+	}
+	n = 0
+	for range db.ChangesSince(last) {
+		n++
+	}
+	if n != 3 {
+		t.Error("must be 3 changes after trim", n)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChangesLongKey(t *testing.T) {
+	f := "test/changes_long"
+	DeleteFile(f)
+	db, err := Open(f, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.Repeat("k", 5000)
+	if err = db.Set(key, []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	db, err = Open(f, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	n := 0
+	for c, err := range db.ChangesSince(0) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(c.Key) != key || string(c.Value) != "v" {
+			t.Error("bad change", len(c.Key), string(c.Value))
+		}
+		n++
+	}
+	if n != 1 {
+		t.Error("must be 1 change", n)
+	}
+}
@@ -0,0 +1,59 @@
+// Command fudge-http serve fudge databases over HTTP with package fudgehttp.
+//
+//	fudge-http -addr :8080 -dir data
+//	curl -X PUT --data-binary world localhost:8080/db/test/keys/hello
+//	curl localhost:8080/db/test/keys?prefix=he
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gnuos/fudge"
+	"github.com/gnuos/fudge/fudgehttp"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	dir := flag.String("dir", "data", "directory of database files")
+	syncInterval := flag.Int("sync", 1, "fsync interval in seconds, 0 - no fsync")
+	flag.Parse()
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: fudgehttp.NewHandler(*dir, &fudge.Config{FileMode: 0644, DirMode: 0755, SyncInterval: *syncInterval}),
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			log.Println("shutdown err:", err)
+		}
+	}()
+	log.Println("fudge-http listen on", *addr)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		// wait for active requests
+		<-shutdown
+		err = nil
+	}
+	if cerr := fudge.CloseAll(); cerr != nil {
+		log.Println("close err:", cerr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("fudge-http stopped")
+}
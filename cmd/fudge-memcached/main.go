@@ -0,0 +1,70 @@
+// Command fudge-memcached serve fudge database over memcached text protocol.
+//
+//	fudge-memcached -addr :11211 -db data/cache
+//	printf 'set hello 0 60 5\r\nworld\r\n' | nc localhost 11211
+//
+// Supported commands: get, gets, set, add, replace, cas, delete, incr, decr, version, quit.
+// Flags and expiration are stored with values, cas unique is revision of key.
+// Expired keys are removed every -sweep seconds.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gnuos/fudge"
+)
+
+func main() {
+	addr := flag.String("addr", ":11211", "listen address")
+	file := flag.String("db", "data/memcached", "database file")
+	syncInterval := flag.Int("sync", 1, "fsync interval in seconds, 0 - no fsync")
+	sweep := flag.Int("sweep", 60, "interval of expired keys removal in seconds")
+	flag.Parse()
+
+	db, err := fudge.Open(*file, &fudge.Config{FileMode: 0644, DirMode: 0755, SyncInterval: *syncInterval})
+	if err != nil {
+		log.Fatal(err)
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	if *sweep > 0 {
+		go func() {
+			t := time.NewTicker(time.Duration(*sweep) * time.Second)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					if _, err := db.DeleteExpired(); err != nil {
+						log.Println("sweep err:", err)
+					}
+				}
+			}
+		}()
+	}
+	log.Println("fudge-memcached listen on", l.Addr())
+	err = newServer(db).serve(l)
+	if cerr := fudge.CloseAll(); cerr != nil {
+		log.Println("close err:", cerr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("fudge-memcached stopped")
+}
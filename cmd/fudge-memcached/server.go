@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gnuos/fudge"
+)
+
+// maxRelative is max exptime in seconds relative to now,
+// greater exptime is unix time
+const maxRelative = 60 * 60 * 24 * 30
+
+// maxValue is max size of value
+const maxValue = 64 << 20
+
+const version = "1.6.0-fudge"
+
+var errBadFormat = errors.New("CLIENT_ERROR bad command line format")
+
+// server serve memcached clients with db
+type server struct {
+	db *fudge.DB
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+func newServer(db *fudge.DB) *server {
+	return &server{db: db, conns: make(map[net.Conn]struct{})}
+}
+
+// serve accept clients until l is closed, then close clients and wait for them
+func (s *server) serve(l net.Listener) error {
+	defer func() {
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+		s.wg.Wait()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			conn.Close()
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// handle execute commands of client until quit or error
+func (s *server) handle(rw io.ReadWriter) {
+	r := bufio.NewReader(rw)
+	w := bufio.NewWriter(rw)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		args := bytes.Fields(line)
+		if len(args) == 0 {
+			w.WriteString("ERROR\r\n")
+		} else if string(args[0]) == "quit" {
+			w.Flush()
+			return
+		} else if err = s.exec(r, w, args); err != nil {
+			if err != errBadFormat {
+				return
+			}
+			w.WriteString(err.Error() + "\r\n")
+		}
+		if r.Buffered() == 0 {
+			// flush after last command of pipeline
+			if w.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec run command, return error if connection must be closed or errBadFormat
+func (s *server) exec(r *bufio.Reader, w *bufio.Writer, args [][]byte) error {
+	cmd, args := string(args[0]), args[1:]
+	noreply := len(args) > 0 && string(args[len(args)-1]) == "noreply"
+	if noreply {
+		args = args[:len(args)-1]
+		// replies are written to discarded buffer
+		w = bufio.NewWriter(io.Discard)
+	}
+	switch cmd {
+	case "get", "gets":
+		if len(args) == 0 || noreply {
+			return errBadFormat
+		}
+		s.get(w, args, cmd == "gets")
+	case "set", "add", "replace", "cas":
+		return s.store(r, w, cmd, args)
+	case "delete":
+		if len(args) != 1 {
+			return errBadFormat
+		}
+		s.delete(w, args[0])
+	case "incr", "decr":
+		if len(args) != 2 {
+			return errBadFormat
+		}
+		s.incr(w, args[0], args[1], cmd == "incr")
+	case "version":
+		w.WriteString("VERSION " + version + "\r\n")
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return nil
+}
+
+func (s *server) get(w *bufio.Writer, keys [][]byte, cas bool) {
+	for _, k := range keys {
+		var v []byte
+		st, err := s.db.GetWithStat(k, &v)
+		if err == fudge.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		w.WriteString("VALUE ")
+		w.Write(k)
+		w.WriteString(" " + strconv.FormatUint(uint64(st.Flags), 10) + " " + strconv.Itoa(len(v)))
+		if cas {
+			w.WriteString(" " + strconv.FormatUint(st.Revision, 10))
+		}
+		w.WriteString("\r\n")
+		w.Write(v)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// store run set, add, replace and cas:
+// <cmd> <key> <flags> <exptime> <bytes> [<cas unique>]
+func (s *server) store(r *bufio.Reader, w *bufio.Writer, cmd string, args [][]byte) error {
+	n := 4
+	if cmd == "cas" {
+		n = 5
+	}
+	if len(args) != n || !validKey(args[0]) {
+		return errBadFormat
+	}
+	flags, err1 := strconv.ParseUint(string(args[1]), 10, 32)
+	exptime, err2 := strconv.ParseInt(string(args[2]), 10, 64)
+	size, err3 := strconv.Atoi(string(args[3]))
+	if err1 != nil || err2 != nil || err3 != nil || size < 0 || size > maxValue {
+		return errBadFormat
+	}
+	opts := &fudge.SetOptions{Flags: uint32(flags), Expire: expireTime(exptime)}
+	switch cmd {
+	case "add":
+		opts.IfNotExists = true
+	case "replace":
+		opts.IfExists = true
+	case "cas":
+		rev, err := strconv.ParseUint(string(args[4]), 10, 64)
+		if err != nil || rev == 0 {
+			return errBadFormat
+		}
+		opts.Revision = rev
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if data[size] != '\r' || data[size+1] != '\n' {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+	ok, err := s.db.SetWith(args[0], data[:size], opts)
+	switch {
+	case err == fudge.ErrKeyNotFound:
+		w.WriteString("NOT_FOUND\r\n")
+	case err != nil:
+		serverError(w, err)
+	case ok:
+		w.WriteString("STORED\r\n")
+	case cmd == "cas":
+		w.WriteString("EXISTS\r\n")
+	default:
+		w.WriteString("NOT_STORED\r\n")
+	}
+	return nil
+}
+
+func (s *server) delete(w *bufio.Writer, k []byte) {
+	err := s.db.Delete(k)
+	switch {
+	case err == fudge.ErrKeyNotFound:
+		w.WriteString("NOT_FOUND\r\n")
+	case err != nil:
+		serverError(w, err)
+	default:
+		w.WriteString("DELETED\r\n")
+	}
+}
+
+// incr change decimal value, flags and expiration are kept.
+// Value is stored with revision check and retried on concurrent change.
+func (s *server) incr(w *bufio.Writer, k, delta []byte, incr bool) {
+	d, err := strconv.ParseUint(string(delta), 10, 64)
+	if err != nil {
+		w.WriteString("CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+	for {
+		var v []byte
+		st, err := s.db.GetWithStat(k, &v)
+		if err == fudge.ErrKeyNotFound {
+			w.WriteString("NOT_FOUND\r\n")
+			return
+		}
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		n, err := strconv.ParseUint(string(bytes.TrimSpace(v)), 10, 64)
+		if err != nil {
+			w.WriteString("CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+			return
+		}
+		switch {
+		case incr:
+			n += d
+		case d > n:
+			n = 0
+		default:
+			n -= d
+		}
+		res := strconv.FormatUint(n, 10)
+		ok, err := s.db.SetWith(k, []byte(res), &fudge.SetOptions{Flags: st.Flags, Expire: st.Expire, Revision: st.Revision})
+		if err == fudge.ErrKeyNotFound {
+			w.WriteString("NOT_FOUND\r\n")
+			return
+		}
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		if ok {
+			w.WriteString(res + "\r\n")
+			return
+		}
+	}
+}
+
+func serverError(w *bufio.Writer, err error) {
+	w.WriteString("SERVER_ERROR " + err.Error() + "\r\n")
+}
+
+// expireTime convert memcached exptime: 0 - never, negative - expired,
+// up to 30 days - seconds from now, greater - unix time
+func expireTime(exptime int64) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return time.Unix(1, 0)
+	case exptime <= maxRelative:
+		return time.Now().Add(time.Duration(exptime) * time.Second)
+	}
+	return time.Unix(exptime, 0)
+}
+
+// validKey return true for key up to 250 bytes without control characters
+func validKey(k []byte) bool {
+	if len(k) == 0 || len(k) > 250 {
+		return false
+	}
+	for _, c := range k {
+		if c <= ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
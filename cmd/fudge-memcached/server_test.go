@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gnuos/fudge"
+)
+
+func TestServer(t *testing.T) {
+	defer fudge.CloseAll()
+	db, err := fudge.Open(filepath.Join(t.TempDir(), "cache"), &fudge.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- newServer(db).serve(l) }()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	// do send request and read reply up to line with one of ends
+	do := func(req string, ends ...string) string {
+		t.Helper()
+		if _, err := io.WriteString(conn, req); err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.WriteString(line)
+			for _, e := range ends {
+				if strings.HasPrefix(line, e) {
+					return b.String()
+				}
+			}
+			if len(ends) == 0 {
+				return b.String()
+			}
+		}
+	}
+
+	tests := []struct {
+		req, want string
+	}{
+		{"set a 5 0 5\r\nhello\r\n", "STORED\r\n"},
+		{"get a b\r\n", "VALUE a 5 5\r\nhello\r\nEND\r\n"},
+		{"add a 0 0 1\r\nx\r\n", "NOT_STORED\r\n"},
+		{"replace b 0 0 1\r\nx\r\n", "NOT_STORED\r\n"},
+		{"add b 0 0 2\r\n10\r\n", "STORED\r\n"},
+		{"incr b 5\r\n", "15\r\n"},
+		{"decr b 20\r\n", "0\r\n"},
+		{"incr a 1\r\n", "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n"},
+		{"incr nope 1\r\n", "NOT_FOUND\r\n"},
+		{"cas nope 0 0 1 1\r\nx\r\n", "NOT_FOUND\r\n"},
+		{"set old 0 -1 1\r\nx\r\n", "STORED\r\n"},
+		{"get old\r\n", "END\r\n"},
+		{"set quiet 0 0 1 noreply\r\nq\r\nget quiet\r\n", "VALUE quiet 0 1\r\nq\r\nEND\r\n"},
+		{"delete b\r\n", "DELETED\r\n"},
+		{"delete b\r\n", "NOT_FOUND\r\n"},
+		{"set a 0 0\r\n", "CLIENT_ERROR bad command line format\r\n"},
+		{"bogus\r\n", "ERROR\r\n"},
+		{"version\r\n", "VERSION " + version + "\r\n"},
+	}
+	for _, tt := range tests {
+		if got := do(tt.req, "END", "STORED", "NOT_", "DELETED", "CLIENT_ERROR", "ERROR", "VERSION", "EXISTS", "0", "1"); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.req, got, tt.want)
+		}
+	}
+
+	// cas with unique from gets
+	line := do("gets a\r\n", "END")
+	fields := strings.Fields(strings.SplitN(line, "\r\n", 2)[0])
+	if len(fields) != 5 {
+		t.Fatal("bad gets", line)
+	}
+	rev, _ := strconv.ParseUint(fields[4], 10, 64)
+	if got := do("cas a 1 0 3 "+strconv.FormatUint(rev+1, 10)+"\r\nnew\r\n", "EXISTS", "STORED"); got != "EXISTS\r\n" {
+		t.Error("cas must fail", got)
+	}
+	if got := do("cas a 1 0 3 "+fields[4]+"\r\nnew\r\n", "EXISTS", "STORED"); got != "STORED\r\n" {
+		t.Error("cas must store", got)
+	}
+	if got := do("get a\r\n", "END"); got != "VALUE a 1 3\r\nnew\r\nEND\r\n" {
+		t.Error("bad value after cas", got)
+	}
+	if st, err := db.Stat("a"); err != nil || st.Flags != 1 {
+		t.Error("flags must be stored in db", st, err)
+	}
+	io.WriteString(conn, "quit\r\n")
+	if _, err = r.ReadByte(); err != io.EOF {
+		t.Error("connection must be closed", err)
+	}
+	l.Close()
+	if err = <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpireTime(t *testing.T) {
+	if !expireTime(0).IsZero() {
+		t.Error("0 must never expire")
+	}
+	if expireTime(1700000000).Unix() != 1700000000 {
+		t.Error("big exptime is unix time")
+	}
+	if d := expireTime(60).Unix() - time.Now().Unix(); d < 59 || d > 61 {
+		t.Error("small exptime is relative", d)
+	}
+}
@@ -0,0 +1,50 @@
+// Command fudge-server serve fudge databases over Redis RESP2 protocol.
+//
+//	fudge-server -addr :6379 -dir data
+//	redis-cli SET hello world
+//
+// Database n of SELECT is file dir/n, keys and values are stored as raw bytes.
+// Supported commands: PING, ECHO, QUIT, SELECT, GET, SET [NX|XX], DEL, EXISTS,
+// KEYS, SCAN, DBSIZE.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gnuos/fudge"
+)
+
+func main() {
+	addr := flag.String("addr", ":6379", "listen address")
+	dir := flag.String("dir", "data", "directory of database files")
+	databases := flag.Int("databases", 16, "number of databases for SELECT")
+	syncInterval := flag.Int("sync", 1, "fsync interval in seconds, 0 - no fsync")
+	flag.Parse()
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	log.Println("fudge-server listen on", l.Addr())
+	s := newServer(*dir, *databases, &fudge.Config{FileMode: 0644, DirMode: 0755, SyncInterval: *syncInterval})
+	err = s.serve(l)
+	if cerr := fudge.CloseAll(); cerr != nil {
+		log.Println("close err:", cerr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("fudge-server stopped")
+}
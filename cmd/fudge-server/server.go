@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gnuos/fudge"
+)
+
+// server serve Redis clients, database n is file n in dir
+type server struct {
+	dir       string
+	databases int
+	cfg       *fudge.Config
+
+	mu    sync.Mutex
+	dbs   map[int]*fudge.DB
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+func newServer(dir string, databases int, cfg *fudge.Config) *server {
+	return &server{
+		dir:       dir,
+		databases: databases,
+		cfg:       cfg,
+		dbs:       make(map[int]*fudge.DB),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// serve accept clients until l is closed, then close clients and wait for them
+func (s *server) serve(l net.Listener) error {
+	defer func() {
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+		s.wg.Wait()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			conn.Close()
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// open return database n, opened with fudge.Open on first use
+func (s *server) open(n int) (*fudge.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if db, ok := s.dbs[n]; ok {
+		return db, nil
+	}
+	db, err := fudge.Open(filepath.Join(s.dir, strconv.Itoa(n)), s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.dbs[n] = db
+	return db, nil
+}
+
+// session is state of one client
+type session struct {
+	s  *server
+	db *fudge.DB
+	w  writer
+}
+
+// handle execute commands of client until QUIT or error
+func (s *server) handle(rw io.ReadWriter) {
+	r := bufio.NewReader(rw)
+	c := &session{s: s, w: writer{bufio.NewWriter(rw)}}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				c.w.error(err.Error())
+				c.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := c.exec(args)
+		if r.Buffered() == 0 || quit {
+			// flush after last command of pipeline
+			if c.w.Flush() != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// command execute command with args, args[0] is name of command
+type command struct {
+	arity int // number of args with name, -n is minimum
+	run   func(c *session, args [][]byte)
+	db    bool // command use selected database
+}
+
+var commands = map[string]command{
+	"ping":    {-1, (*session).ping, false},
+	"echo":    {2, (*session).echo, false},
+	"quit":    {1, func(c *session, _ [][]byte) { c.w.simple("OK") }, false},
+	"command": {-1, func(c *session, _ [][]byte) { c.w.array(0) }, false},
+	"select":  {2, (*session).selectDB, false},
+	"get":     {2, (*session).get, true},
+	"set":     {-3, (*session).set, true},
+	"del":     {-2, (*session).del, true},
+	"exists":  {-2, (*session).exists, true},
+	"keys":    {2, (*session).keys, true},
+	"scan":    {-2, (*session).scan, true},
+	"dbsize":  {1, (*session).dbsize, true},
+}
+
+// exec run command, return true if connection must be closed
+func (c *session) exec(args [][]byte) bool {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		c.w.error("ERR unknown command '" + string(args[0]) + "'")
+		return false
+	}
+	if cmd.arity > 0 && len(args) != cmd.arity || cmd.arity < 0 && len(args) < -cmd.arity {
+		c.w.error("ERR wrong number of arguments for '" + name + "' command")
+		return false
+	}
+	if cmd.db && c.db == nil {
+		db, err := c.s.open(0)
+		if err != nil {
+			c.w.error("ERR " + err.Error())
+			return false
+		}
+		c.db = db
+	}
+	cmd.run(c, args)
+	return name == "quit"
+}
+
+func (c *session) ping(args [][]byte) {
+	switch len(args) {
+	case 1:
+		c.w.simple("PONG")
+	case 2:
+		c.w.bulk(args[1])
+	default:
+		c.w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (c *session) echo(args [][]byte) {
+	c.w.bulk(args[1])
+}
+
+func (c *session) selectDB(args [][]byte) {
+	n, err := strconv.Atoi(string(args[1]))
+	if err != nil {
+		c.w.error("ERR value is not an integer or out of range")
+		return
+	}
+	if n < 0 || n >= c.s.databases {
+		c.w.error("ERR DB index is out of range")
+		return
+	}
+	db, err := c.s.open(n)
+	if err != nil {
+		c.w.error("ERR " + err.Error())
+		return
+	}
+	c.db = db
+	c.w.simple("OK")
+}
+
+func (c *session) get(args [][]byte) {
+	var v []byte
+	err := c.db.Get(args[1], &v)
+	if err == fudge.ErrKeyNotFound {
+		c.w.bulk(nil)
+		return
+	}
+	if err != nil {
+		c.w.error("ERR " + err.Error())
+		return
+	}
+	if v == nil {
+		v = []byte{}
+	}
+	c.w.bulk(v)
+}
+
+// set support NX and XX options
+func (c *session) set(args [][]byte) {
+	nx, xx := false, false
+	for _, opt := range args[3:] {
+		switch strings.ToLower(string(opt)) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		default:
+			c.w.error("ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		c.w.error("ERR syntax error")
+		return
+	}
+	k, v := args[1], args[2]
+	var ok bool
+	var err error
+	switch {
+	case nx:
+		ok, err = c.db.SetIfNotExists(k, v)
+	case xx:
+		ok, err = c.db.SetIfExists(k, v)
+	default:
+		ok, err = true, c.db.Set(k, v)
+	}
+	switch {
+	case err != nil:
+		c.w.error("ERR " + err.Error())
+	case !ok:
+		c.w.bulk(nil)
+	default:
+		c.w.simple("OK")
+	}
+}
+
+func (c *session) del(args [][]byte) {
+	n := 0
+	for _, k := range args[1:] {
+		err := c.db.Delete(k)
+		if err == nil {
+			n++
+		} else if err != fudge.ErrKeyNotFound {
+			c.w.error("ERR " + err.Error())
+			return
+		}
+	}
+	c.w.int(n)
+}
+
+func (c *session) exists(args [][]byte) {
+	n := 0
+	for _, k := range args[1:] {
+		if ok, _ := c.db.Has(k); ok {
+			n++
+		}
+	}
+	c.w.int(n)
+}
+
+// keys return keys matched by glob pattern,
+// literal prefix of pattern is used for prefix scan
+func (c *session) keys(args [][]byte) {
+	pattern := args[1]
+	prefix := pattern[:len(pattern)-len(globMeta(pattern))]
+	var keys [][]byte
+	var err error
+	if len(prefix) > 0 {
+		keys, err = c.db.KeysByPrefix(prefix, 0, 0, true)
+	} else {
+		keys, err = c.db.Keys(nil, 0, 0, true)
+	}
+	if err != nil && err != fudge.ErrKeyNotFound {
+		c.w.error("ERR " + err.Error())
+		return
+	}
+	matched := keys[:0]
+	for _, k := range keys {
+		if match(pattern, k) {
+			matched = append(matched, k)
+		}
+	}
+	c.w.array(len(matched))
+	for _, k := range matched {
+		c.w.bulk(k)
+	}
+}
+
+// scan return page of keys, cursor is offset of next page in key order.
+// Keys added or deleted while scanning may shift pages.
+func (c *session) scan(args [][]byte) {
+	cursor, err := strconv.Atoi(string(args[1]))
+	if err != nil || cursor < 0 {
+		c.w.error("ERR invalid cursor")
+		return
+	}
+	count := 10
+	var pattern []byte
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			c.w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToLower(string(args[i])) {
+		case "match":
+			pattern = args[i+1]
+		case "count":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil || count < 1 {
+				c.w.error("ERR value is not an integer or out of range")
+				return
+			}
+		default:
+			c.w.error("ERR syntax error")
+			return
+		}
+	}
+	keys, err := c.db.Keys(nil, count, cursor, true)
+	if err != nil && err != fudge.ErrKeyNotFound {
+		c.w.error("ERR " + err.Error())
+		return
+	}
+	next := 0
+	if len(keys) == count {
+		next = cursor + count
+	}
+	matched := keys[:0]
+	for _, k := range keys {
+		if pattern == nil || match(pattern, k) {
+			matched = append(matched, k)
+		}
+	}
+	c.w.array(2)
+	c.w.bulk([]byte(strconv.Itoa(next)))
+	c.w.array(len(matched))
+	for _, k := range matched {
+		c.w.bulk(k)
+	}
+}
+
+func (c *session) dbsize(_ [][]byte) {
+	n, err := c.db.Count()
+	if err != nil {
+		c.w.error("ERR " + err.Error())
+		return
+	}
+	c.w.int(n)
+}
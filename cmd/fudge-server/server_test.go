@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gnuos/fudge"
+)
+
+// client send commands in RESP and read raw replies
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *client) do(args ...string) string {
+	c.t.Helper()
+	b := new(strings.Builder)
+	w := writer{bufio.NewWriter(b)}
+	w.array(len(args))
+	for _, a := range args {
+		w.bulk([]byte(a))
+	}
+	w.Flush()
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.reply()
+}
+
+// reply read one reply, arrays are joined with spaces
+func (c *client) reply() string {
+	c.t.Helper()
+	line, err := readLine(c.r)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	switch line[0] {
+	case '$':
+		if string(line) == "$-1" {
+			return "(nil)"
+		}
+		b, err := readLine(c.r)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		return string(b)
+	case '*':
+		n := 0
+		for _, d := range line[1:] {
+			n = n*10 + int(d-'0')
+		}
+		items := make([]string, n)
+		for i := range items {
+			items[i] = c.reply()
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	}
+	return string(line)
+}
+
+func TestServer(t *testing.T) {
+	defer fudge.CloseAll()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(t.TempDir(), 2, &fudge.Config{})
+	done := make(chan error)
+	go func() { done <- s.serve(l) }()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, "+PONG"},
+		{[]string{"SET", "user:1", "alice"}, "+OK"},
+		{[]string{"SET", "user:2", "bob"}, "+OK"},
+		{[]string{"set", "user:2", "eve", "NX"}, "(nil)"},
+		{[]string{"SET", "user:3", "carol", "XX"}, "(nil)"},
+		{[]string{"SET", "order:1", ""}, "+OK"},
+		{[]string{"GET", "user:2"}, "bob"},
+		{[]string{"GET", "order:1"}, ""},
+		{[]string{"GET", "nope"}, "(nil)"},
+		{[]string{"EXISTS", "user:1", "user:3", "order:1"}, ":2"},
+		{[]string{"KEYS", "user:*"}, "[user:1 user:2]"},
+		{[]string{"KEYS", "*:[1]"}, "[order:1 user:1]"},
+		{[]string{"DBSIZE"}, ":3"},
+		{[]string{"SCAN", "0", "COUNT", "2"}, "[2 [order:1 user:1]]"},
+		{[]string{"SCAN", "2", "COUNT", "2"}, "[0 [user:2]]"},
+		{[]string{"SCAN", "0", "MATCH", "user:*"}, "[0 [user:1 user:2]]"},
+		{[]string{"DEL", "user:1", "user:3"}, ":1"},
+		{[]string{"SELECT", "1"}, "+OK"},
+		{[]string{"DBSIZE"}, ":0"},
+		{[]string{"SELECT", "2"}, "-ERR DB index is out of range"},
+		{[]string{"SELECT", "0"}, "+OK"},
+		{[]string{"DBSIZE"}, ":2"},
+		{[]string{"GET"}, "-ERR wrong number of arguments for 'get' command"},
+		{[]string{"FLUSHALL"}, "-ERR unknown command 'FLUSHALL'"},
+	}
+	for _, tt := range tests {
+		if got := c.do(tt.args...); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// inline command
+	io.WriteString(conn, "ECHO hello\r\n")
+	if got := c.reply(); got != "hello" {
+		t.Error("bad inline reply", got)
+	}
+	if got := c.do("QUIT"); got != "+OK" {
+		t.Error("bad quit", got)
+	}
+	l.Close()
+	if err = <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users", false},
+		{"h?llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"a\\*", "a*", true},
+		{"a\\*", "ab", false},
+		{"*b*c", "abxbc", true},
+	}
+	for _, tt := range tests {
+		if got := match([]byte(tt.pattern), []byte(tt.s)); got != tt.want {
+			t.Errorf("match(%q, %q) = %v", tt.pattern, tt.s, got)
+		}
+	}
+}
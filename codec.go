@@ -0,0 +1,141 @@
+package fudge
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var (
+	// ErrCodec - value stored with unknown codec
+	ErrCodec = errors.New("error: unknown codec")
+	// ErrCodecID - codec id is reserved or registered by another codec
+	ErrCodecID = errors.New("error: codec id is reserved or registered")
+)
+
+// Codec encode and decode values.
+// ID is stored in every index record, so values written with other codec
+// stay readable after codec change, codec of other id must be registered
+// with RegisterCodec to read them. IDs 0-127 are reserved for fudge codecs.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(b []byte, v any) error
+	ID() uint8
+}
+
+var (
+	// CBORCodec is canonical CBOR, default codec
+	CBORCodec Codec = cborCodec{}
+	// JSONCodec is encoding/json
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec is encoding/gob, every value is encoded with its own type description
+	GobCodec Codec = gobCodec{}
+)
+
+// codecs known by id
+var codecs = struct {
+	sync.RWMutex
+	m map[uint8]Codec
+}{m: map[uint8]Codec{
+	CBORCodec.ID(): CBORCodec,
+	JSONCodec.ID(): JSONCodec,
+	GobCodec.ID():  GobCodec,
+}}
+
+// RegisterCodec make values written with codec c readable by db opened with another codec.
+// Codec of Config is registered by Open. Return ErrCodecID if id of c is reserved (0-127)
+// or registered by codec of another type.
+func RegisterCodec(c Codec) error {
+	codecs.Lock()
+	defer codecs.Unlock()
+	if old, ok := codecs.m[c.ID()]; ok {
+		if reflect.TypeOf(old) != reflect.TypeOf(c) {
+			return ErrCodecID
+		}
+		return nil
+	}
+	if c.ID() < 128 {
+		return ErrCodecID
+	}
+	codecs.m[c.ID()] = c
+	return nil
+}
+
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v any) ([]byte, error) {
+	return marshal(v)
+}
+
+func (cborCodec) Unmarshal(b []byte, v any) error {
+	return cbor.Unmarshal(b, v)
+}
+
+func (cborCodec) ID() uint8 {
+	return 0
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(b []byte, v any) error {
+	return json.Unmarshal(b, v)
+}
+
+func (jsonCodec) ID() uint8 {
+	return 1
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := gob.NewEncoder(buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(b []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+func (gobCodec) ID() uint8 {
+	return 2
+}
+
+// valToBinary return value in bytes with db codec
+func (db *DB) valToBinary(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	default:
+		return db.codec.Marshal(v)
+	}
+}
+
+// unmarshal decode value stored with codec id
+// *[]byte receive value as is
+func (db *DB) unmarshal(id uint8, b []byte, value any) error {
+	if raw, ok := value.(*[]byte); ok {
+		*raw = b
+		return nil
+	}
+	c := db.codec
+	if id != c.ID() {
+		var ok bool
+		codecs.RLock()
+		c, ok = codecs.m[id]
+		codecs.RUnlock()
+		if !ok {
+			return ErrCodec
+		}
+	}
+	return c.Unmarshal(b, value)
+}
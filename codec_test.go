@@ -0,0 +1,117 @@
+package fudge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	f := "test/codec"
+	DeleteFile(f)
+	db, err := Open(f, &Config{Codec: JSONCodec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("json", user{"Alice", 30})
+	var raw []byte
+	db.Get("json", &raw)
+	var u user
+	if err = json.Unmarshal(raw, &u); err != nil || u.Name != "Alice" {
+		t.Error("value must be json", string(raw), err)
+	}
+	err = db.Update(func(tx *Tx) error {
+		return tx.Set("tx", user{"Bob", 40})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// migrate to gob: old values are readable, new values written with gob
+	db, err = Open(f, &Config{Codec: GobCodec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("gob", user{"Carol", 50})
+	db.Close()
+
+	db, err = Open(f, &Config{StoreMode: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, name := range map[string]string{"json": "Alice", "tx": "Bob", "gob": "Carol"} {
+		u = user{}
+		err = db.Get(k, &u)
+		if err != nil || u.Name != name {
+			t.Error("bad value", k, u, err)
+		}
+	}
+	db.Set("cbor", 1)
+	db.Close()
+
+	db, err = Open(f, &Config{Codec: JSONCodec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var i int
+	db.Get("cbor", &i)
+	u = user{}
+	db.Get("gob", &u)
+	if i != 1 || u.Name != "Carol" {
+		t.Error("values must survive memory mode", i, u)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// customCodec is json codec with custom id
+type customCodec struct {
+	jsonCodec
+	id uint8
+}
+
+func (c customCodec) ID() uint8 {
+	return c.id
+}
+
+// otherCodec is codec of another type
+type otherCodec struct {
+	customCodec
+}
+
+func TestRegisterCodec(t *testing.T) {
+	if err := RegisterCodec(customCodec{id: 1}); err != ErrCodecID {
+		t.Error("reserved id must be rejected", err)
+	}
+	f := "test/codec_custom"
+	DeleteFile(f)
+	if _, err := Open(f, &Config{Codec: customCodec{id: 2}}); err != ErrCodecID {
+		t.Fatal("reserved id of config must be rejected", err)
+	}
+	db, err := Open(f, &Config{Codec: customCodec{id: 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RegisterCodec(otherCodec{customCodec{id: 200}}); err != ErrCodecID {
+		t.Error("registered id must be rejected", err)
+	}
+	db.Set("k", 1)
+	db.Close()
+
+	// custom codec is registered by Open
+	db, err = Open(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	var v int
+	if err = db.Get("k", &v); err != nil || v != 1 {
+		t.Error("value of custom codec must be readable", v, err)
+	}
+}
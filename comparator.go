@@ -0,0 +1,89 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// ErrComparator - db was created with another comparator
+var ErrComparator = errors.New("error: comparator mismatch")
+
+// Comparator define order of keys in db.
+// Name is stored in index file, db can't be opened with comparator of another name.
+// Prefix scans expect that keys with same prefix are adjacent in comparator order.
+type Comparator interface {
+	Compare(a, b []byte) int
+	Name() string
+}
+
+// BytewiseComparator order keys with bytes.Compare, default comparator
+var BytewiseComparator Comparator = bytewise{}
+
+type bytewise struct{}
+
+func (bytewise) Compare(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+func (bytewise) Name() string {
+	return "fudge.Bytewise"
+}
+
+// compare keys with db comparator
+func (db *DB) compare(a, b []byte) int {
+	return db.cmp.Compare(a, b)
+}
+
+// seekGE return index of first key greater or equal b, keys must be sorted
+func (db *DB) seekGE(b []byte) int {
+	return sort.Search(len(db.keys), func(i int) bool {
+		return db.compare(db.keys[i], b) >= 0
+	})
+}
+
+// seekGT return index of first key greater than b, keys must be sorted
+func (db *DB) seekGT(b []byte) int {
+	return sort.Search(len(db.keys), func(i int) bool {
+		return db.compare(db.keys[i], b) > 0
+	})
+}
+
+// indexOf return index of key b, keys must be sorted
+func (db *DB) indexOf(b []byte) (int, bool) {
+	for i := db.seekGE(b); i < len(db.keys) && db.compare(db.keys[i], b) == 0; i++ {
+		if bytes.Equal(db.keys[i], b) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// prefixFirst return index of first key with prefix or len(keys)
+func (db *DB) prefixFirst(prefix []byte) int {
+	if _, ok := db.cmp.(bytewise); ok {
+		return db.seekGE(prefix)
+	}
+	for i := range db.keys {
+		if bytes.HasPrefix(db.keys[i], prefix) {
+			return i
+		}
+	}
+	return len(db.keys)
+}
+
+// prefixLast return index of last key with prefix or -1
+func (db *DB) prefixLast(prefix []byte) int {
+	if _, ok := db.cmp.(bytewise); ok {
+		if end := prefixEnd(prefix); end != nil {
+			return db.seekGE(end) - 1
+		}
+		return len(db.keys) - 1
+	}
+	for i := len(db.keys) - 1; i >= 0; i-- {
+		if bytes.HasPrefix(db.keys[i], prefix) {
+			return i
+		}
+	}
+	return -1
+}
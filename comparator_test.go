@@ -0,0 +1,64 @@
+package fudge
+
+import (
+	"bytes"
+	"testing"
+)
+
+type reverse struct{}
+
+func (reverse) Compare(a, b []byte) int { return bytes.Compare(b, a) }
+func (reverse) Name() string            { return "reverse" }
+
+func TestComparator(t *testing.T) {
+	f := "test/comparator"
+	DeleteFile(f)
+	db, err := Open(f, &Config{Comparator: reverse{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a1", "b1", "b2", "b3", "c1"} {
+		db.Set(k, k)
+	}
+	keys, _ := db.Keys(nil, 0, 0, true)
+	if string(keys[0]) != "c1" || string(keys[4]) != "a1" {
+		t.Error("keys must be in reverse order", keys)
+	}
+	keys, _ = db.KeysByPrefix([]byte("b"), 0, 0, true)
+	if len(keys) != 3 || string(keys[0]) != "b3" {
+		t.Error("prefix keys must be in reverse order", keys)
+	}
+	res := make([]string, 0)
+	it := db.NewIterator(&IteratorOptions{Prefix: []byte("b")})
+	for ok := it.Last(); ok; ok = it.Prev() {
+		res = append(res, string(it.Key()))
+	}
+	if len(res) != 3 || res[0] != "b1" || res[2] != "b3" {
+		t.Error("bad iterator order", res)
+	}
+	keys, _ = db.KeysRange("c1", "a1", nil)
+	if len(keys) != 4 || string(keys[3]) != "b1" {
+		t.Error("bad range", keys)
+	}
+	db.Delete("b2")
+	if c, _ := db.Count(); c != 4 {
+		t.Error("count must be 4", c)
+	}
+	db.Close()
+
+	if _, err = Open(f, &Config{}); err != ErrComparator {
+		t.Error("must be ErrComparator", err)
+	}
+	db, err = Open(f, &Config{Comparator: reverse{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = db.Keys(nil, 0, 0, true)
+	if len(keys) != 4 || string(keys[0]) != "c1" {
+		t.Error("keys must be in reverse order", keys)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
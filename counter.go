@@ -0,0 +1,64 @@
+package fudge
+
+// Incr increment integer counter by 1 and return new value.
+// Not existing counter starts from 0.
+func (db *DB) Incr(key any) (int64, error) {
+	return db.IncrBy(key, 1)
+}
+
+// Decr decrement integer counter by 1 and return new value.
+// Not existing counter starts from 0.
+func (db *DB) Decr(key any) (int64, error) {
+	return db.IncrBy(key, -1)
+}
+
+// Counter increment integer counter by incr and return new value.
+// Not existing counter starts from 0.
+func (db *DB) Counter(key any, incr int) (int, error) {
+	v, err := db.IncrBy(key, int64(incr))
+	return int(v), err
+}
+
+// IncrBy increment integer counter by delta and return new value.
+// Not existing counter starts from 0.
+func (db *DB) IncrBy(key any, delta int64) (int64, error) {
+	k, err := db.keyToBinary(key)
+	if err != nil {
+		return 0, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	var cur int64
+	err = db.get(k, &cur)
+	if err != nil && err != ErrKeyNotFound {
+		return 0, err
+	}
+	cur += delta
+	v, err := db.valToBinary(cur)
+	if err != nil {
+		return 0, err
+	}
+	return cur, db.set(k, v)
+}
+
+// IncrByFloat increment float counter by delta and return new value.
+// Not existing counter starts from 0.
+func (db *DB) IncrByFloat(key any, delta float64) (float64, error) {
+	k, err := db.keyToBinary(key)
+	if err != nil {
+		return 0, err
+	}
+	db.Lock()
+	defer db.Unlock()
+	var cur float64
+	err = db.get(k, &cur)
+	if err != nil && err != ErrKeyNotFound {
+		return 0, err
+	}
+	cur += delta
+	v, err := db.valToBinary(cur)
+	if err != nil {
+		return 0, err
+	}
+	return cur, db.set(k, v)
+}
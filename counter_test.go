@@ -0,0 +1,67 @@
+package fudge
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	for _, mode := range []int{0, 2} {
+		f := "test/counter"
+		db, err := Open(f, &Config{StoreMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					db.Incr("hits")
+					db.IncrByFloat("sum", 0.5)
+				}
+			}()
+		}
+		wg.Wait()
+		v, _ := db.Decr("hits")
+		if v != 999 {
+			t.Error("hits must be 999", mode, v)
+		}
+		v, _ = db.IncrBy("hits", 11)
+		if v != 1010 {
+			t.Error("hits must be 1010", mode, v)
+		}
+		c, _ := db.Counter("hits", -10)
+		if c != 1000 {
+			t.Error("hits must be 1000", mode, c)
+		}
+		var sum float64
+		db.Get("sum", &sum)
+		if sum != 500 {
+			t.Error("sum must be 500", mode, sum)
+		}
+		// int counter as float
+		fv, err := db.IncrByFloat("hits", 0.5)
+		if err != nil || fv != 1000.5 {
+			t.Error("hits must be 1000.5", mode, fv, err)
+		}
+		db.Set("str", "str")
+		if _, err = db.Incr("str"); err == nil {
+			t.Error("string counter must fail", mode)
+		}
+		db.Close()
+		db, err = Open(f, &Config{StoreMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Get("sum", &sum)
+		if sum != 500 {
+			t.Error("sum must persist", mode, sum)
+		}
+		err = db.DeleteFile()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package fudge
+
+import (
+	"time"
+)
+
+// SetOptions of SetWith, zero options store value like Set
+type SetOptions struct {
+	Flags       uint32    // opaque flags stored with value, returned by Stat
+	Expire      time.Time // value is not visible after Expire, zero - never
+	Revision    uint64    // store only if revision of key is equal, 0 - any
+	IfNotExists bool      // store only if key not exists
+	IfExists    bool      // store only if key exists
+}
+
+// SetWith store key value with flags and expiration time if conditions of opts are met.
+// Return false if value is not stored, ErrKeyNotFound if opts.Revision is set and key not exists.
+// Expired keys are not visible to Get, Has and Stat, but stay in Keys and Count
+// until DeleteExpired.
+//
+//	db.SetWith("session", token, &fudge.SetOptions{Expire: time.Now().Add(time.Hour)})
+func (db *DB) SetWith(key, value any, opts *SetOptions) (bool, error) {
+	k, v, err := db.keyVal(key, value)
+	if err != nil {
+		return false, err
+	}
+	if opts == nil {
+		opts = &SetOptions{}
+	}
+	db.Lock()
+	defer db.Unlock()
+	cmd, exists := db.live(k)
+	switch {
+	case opts.Revision != 0 && !exists:
+		return false, ErrKeyNotFound
+	case opts.Revision != 0 && cmd.Rev != opts.Revision:
+		return false, nil
+	case opts.IfNotExists && exists, opts.IfExists && !exists:
+		return false, nil
+	}
+	return true, db.put(k, v, db.codec.ID(), opts.Flags, expireUnix(opts.Expire))
+}
+
+// GetWithStat return value by key and its metadata
+// Return error if any.
+func (db *DB) GetWithStat(key any, value any) (*KeyStat, error) {
+	db.RLock()
+	defer db.RUnlock()
+	k, err := db.keyToBinary(key)
+	if err != nil {
+		return nil, err
+	}
+	val, ok := db.live(k)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	b, err := db.readVal(val)
+	if err != nil {
+		return nil, err
+	}
+	return val.stat(), db.unmarshal(val.codec, b, value)
+}
+
+// DeleteExpired remove expired keys of db and return count of removed keys.
+// Watchers get EventExpire for every removed key.
+func (db *DB) DeleteExpired() (int, error) {
+	db.Lock()
+	defer db.Unlock()
+	now := uint32(time.Now().Unix())
+	keys := make([][]byte, 0)
+	for k, cmd := range db.vals {
+		if cmd.expiredAt(now) {
+			keys = append(keys, []byte(k))
+		}
+	}
+	for i, k := range keys {
+		if err := db.remove(k, EventExpire); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// live return value of key if it exists and not expired
+func (db *DB) live(k []byte) (*Cmd, bool) {
+	cmd, ok := db.vals[string(k)]
+	if !ok || cmd.expiredAt(uint32(time.Now().Unix())) {
+		return nil, false
+	}
+	return cmd, true
+}
+
+func (c *Cmd) expiredAt(now uint32) bool {
+	return c.expire != 0 && c.expire <= now
+}
+
+// expireTime return expiration time, zero if value never expire
+func (c *Cmd) expireTime() time.Time {
+	if c.expire == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(c.expire), 0)
+}
+
+func (c *Cmd) stat() *KeyStat {
+	return &KeyStat{Size: c.Size, Revision: c.Rev, Flags: c.flags, Expire: c.expireTime()}
+}
+
+// expireUnix return unix time rounded up to second, 0 for zero time
+func expireUnix(t time.Time) uint32 {
+	if t.IsZero() {
+		return 0
+	}
+	sec := t.Unix()
+	if t.Nanosecond() > 0 {
+		sec++
+	}
+	return uint32(max(sec, 1))
+}
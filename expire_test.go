@@ -0,0 +1,129 @@
+package fudge
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetWith(t *testing.T) {
+	f := "test/expire"
+	DeleteFile(f)
+	db, err := Open(f, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	hour := time.Now().Add(time.Hour).Truncate(time.Second)
+	ok, err := db.SetWith("a", []byte("1"), &SetOptions{Flags: 7, Expire: hour})
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	var v []byte
+	st, err := db.GetWithStat("a", &v)
+	if err != nil || string(v) != "1" || st.Flags != 7 || !st.Expire.Equal(hour) {
+		t.Error("bad stat", st, string(v), err)
+	}
+
+	// conditions
+	if ok, _ = db.SetWith("a", []byte("2"), &SetOptions{IfNotExists: true}); ok {
+		t.Error("a exists")
+	}
+	if ok, _ = db.SetWith("b", []byte("2"), &SetOptions{IfExists: true}); ok {
+		t.Error("b not exists")
+	}
+	if _, err = db.SetWith("b", []byte("2"), &SetOptions{Revision: 1}); err != ErrKeyNotFound {
+		t.Error("must be not found", err)
+	}
+	if ok, _ = db.SetWith("a", []byte("2"), &SetOptions{Revision: st.Revision + 1}); ok {
+		t.Error("revision mismatch")
+	}
+	if ok, _ = db.SetWith("a", []byte("2"), &SetOptions{Revision: st.Revision}); !ok {
+		t.Error("revision must match")
+	}
+	// Set clear flags and expiration
+	db.Set("a", []byte("3"))
+	if st, _ = db.Stat("a"); st.Flags != 0 || !st.Expire.IsZero() {
+		t.Error("flags must be cleared", st)
+	}
+
+	// expired key is not visible, but stay until DeleteExpired
+	db.SetWith("old", []byte("x"), &SetOptions{Flags: 1, Expire: time.Now().Add(-time.Second)})
+	if has, _ := db.Has("old"); has {
+		t.Error("expired key must be hidden")
+	}
+	if err = db.Get("old", &v); err != ErrKeyNotFound {
+		t.Error("expired key must be not found", err)
+	}
+	if ok, _ = db.SetWith("old", []byte("y"), &SetOptions{IfExists: true}); ok {
+		t.Error("expired key not exists")
+	}
+	db.SetWith("c", []byte("c"), &SetOptions{Flags: 3, Expire: hour})
+	db.Close()
+
+	// flags and expiration are stored in index
+	if db, err = Open(f, &Config{ChangeLog: true}); err != nil {
+		t.Fatal(err)
+	}
+	if st, err = db.Stat("c"); err != nil || st.Flags != 3 || !st.Expire.Equal(hour) {
+		t.Error("bad stat after open", st, err)
+	}
+	if n, _ := db.Count(); n != 3 {
+		t.Error("expired key must be counted", n)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := db.Watch(ctx, nil)
+	if n, err := db.DeleteExpired(); n != 1 || err != nil {
+		t.Error("must delete 1 key", n, err)
+	}
+	if e := <-ch; e.Type != EventExpire || string(e.Key) != "old" {
+		t.Error("bad event", e)
+	}
+	if n, _ := db.Count(); n != 2 {
+		t.Error("must be 2 keys", n)
+	}
+	var last Change
+	for c := range db.ChangesSince(0) {
+		if string(c.Key) == "c" {
+			last = c
+		}
+	}
+	if last.Flags != 3 || !last.Expire.Equal(hour) {
+		t.Error("change must keep flags", last)
+	}
+}
+
+func TestExpiredMergeTx(t *testing.T) {
+	f := "test/expire_merge"
+	DeleteFile(f)
+	var seen []byte
+	db, err := Open(f, &Config{Merge: func(key, existing, operand []byte) ([]byte, error) {
+		seen = existing
+		return append(existing, operand...), nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	past := &SetOptions{Expire: time.Now().Add(-time.Second)}
+	db.SetWith("m", []byte("old"), past)
+	if err = db.Merge("m", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	var v []byte
+	if seen != nil || db.Get("m", &v) != nil || string(v) != "x" {
+		t.Error("expired value must not be merged", string(seen), string(v))
+	}
+
+	db.SetWith("t", []byte("old"), past)
+	err = db.Update(func(tx *Tx) error {
+		if has, _ := tx.Has("t"); has {
+			t.Error("expired key must be hidden in tx")
+		}
+		return tx.Delete("t")
+	})
+	if err != ErrKeyNotFound {
+		t.Error("expired key must be not found in tx", err)
+	}
+}
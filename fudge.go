@@ -5,11 +5,13 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"hash/crc32"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,18 +23,66 @@ var (
 
 	// ErrKeyNotFound - key not found
 	ErrKeyNotFound = errors.New("error: key not found")
+	// ErrFormat - unknown index record format
+	ErrFormat = errors.New("error: unknown index format")
+)
+
+// index record versions
+const (
+	recVersion0 uint8 = iota // 16 byte header
+	recVersion1              // 24 byte header with revision
+	recVersion2              // 25 byte header with revision and codec id
+	recVersion3              // 33 byte header with revision, codec id, flags and expire time
+	recVersion  = recVersion3
+)
+
+// index record command codes
+const (
+	recSet         uint8 = iota // set key
+	recDelete                   // delete key
+	recBegin                    // start of transaction, size - count of records
+	recCommit                   // end of transaction, size - count of records
+	recComparator               // name of comparator in key
+	recIndex                    // add secondary index entry in key
+	recUnindex                  // remove secondary index entry in key
+	recBucket                   // create bucket, name in key
+	recDropBucket               // delete bucket, name in key
+	recSyncBegin                // start of snapshot from leader
+	recSyncEnd                  // end of snapshot from leader, rev - revision of snapshot
+	recKeyEncoding              // key encoding of db in size
+
+	// recInBucket flag record of bucket, key is prefixed with u8 length and name of bucket
+	recInBucket uint8 = 0x80
 )
 
 // DB represent database
 type DB struct {
-	sync.RWMutex
+	*sync.RWMutex
 	name         string
 	fk           *os.File
 	fv           *os.File
+	fl           *os.File // change log
 	keys         [][]byte
+	unsorted     atomic.Bool // keys appended after last sort
+	sortMu       sync.Mutex  // sort of keys by readers under read lock
 	vals         map[string]*Cmd
 	cancelSyncer context.CancelFunc
 	storemode    int
+	snapshots    int    // count of not released snapshots
+	rev          uint64 // last revision
+	merge        MergeFunc
+	keyEncoding  int
+	cmp          Comparator
+	codec        Codec
+	indexes      map[string]*index
+	parent       *DB    // db of bucket, nil for db
+	bucket       []byte // key prefix of bucket records
+	buckets      map[string]*DB
+	watchers     map[*watcher]struct{}
+	readonly     bool
+	replicating  bool          // follower apply changes of leader
+	logSignal    chan struct{} // closed on write to change log
+	logGen       int           // incremented on trim of change log
 }
 
 // Cmd represent keys and vals addresses
@@ -41,18 +91,31 @@ type Cmd struct {
 	Size    uint32
 	KeySeek uint32
 	Val     []byte
+	Rev     uint64 // revision of last change
+	ver     uint8  // index record version
+	codec   uint8  // codec id of value
+	flags   uint32 // opaque flags of value
+	expire  uint32 // unix time of expiration, 0 - never
 }
 
 // Config fo db
 // Default FileMode = 0644
 // Default DirMode = 0755
 // Default SyncInterval = 0 sec, 0 - disable sync (os will sync, typically 30 sec or so)
+// Default Shards = 4, used only by OpenSharded
 // If StroreMode==2 && file == "" - pure inmemory mode
 type Config struct {
-	FileMode     int // 0644
-	DirMode      int // 0755
-	SyncInterval int // in seconds
-	StoreMode    int // 0 - file first, 2 - memory first(with persist on close), 2 - with empty file - memory without persist
+	FileMode     int        // 0644
+	DirMode      int        // 0755
+	SyncInterval int        // in seconds
+	StoreMode    int        // 0 - file first, 2 - memory first(with persist on close), 2 - with empty file - memory without persist
+	Shards       int        // number of files for OpenSharded
+	Merge        MergeFunc  // merge operator for DB.Merge
+	KeyEncoding  int        // KeyBinary (default) or KeyOrdered, stored in index, must be same for every open of db
+	Comparator   Comparator // order of keys, BytewiseComparator if nil, must be same for every open of db
+	Codec        Codec      // codec of values, CBORCodec if nil, may be changed between opens
+	ChangeLog    bool       // keep log of changes in file.log for ChangesSince
+	ReadOnly     bool       // reject writes, for replication followers
 }
 
 func init() {
@@ -62,14 +125,31 @@ func init() {
 func newDB(f string, cfg *Config) (*DB, error) {
 	var err error
 	// create
-	db := new(DB)
+	db := &DB{RWMutex: new(sync.RWMutex)}
 	db.Lock()
 	defer db.Unlock()
 	// init
 	db.name = f
 	db.keys = make([][]byte, 0)
 	db.vals = make(map[string]*Cmd)
+	db.indexes = make(map[string]*index)
+	db.buckets = make(map[string]*DB)
 	db.storemode = cfg.StoreMode
+	db.readonly = cfg.ReadOnly
+	db.merge = cfg.Merge
+	db.keyEncoding = cfg.KeyEncoding
+	db.cmp = cfg.Comparator
+	if db.cmp == nil {
+		db.cmp = BytewiseComparator
+	}
+	db.codec = cfg.Codec
+	if db.codec == nil {
+		db.codec = CBORCodec
+	}
+	err = RegisterCodec(db.codec)
+	if err != nil {
+		return nil, err
+	}
 
 	// Apply default values
 	if cfg.FileMode == 0 {
@@ -101,46 +181,150 @@ func newDB(f string, cfg *Config) (*DB, error) {
 	}
 	db.fk, err = os.OpenFile(f+".idx", os.O_CREATE|os.O_RDWR, os.FileMode(cfg.FileMode))
 	if err != nil {
+		db.closeFiles()
 		return nil, err
 	}
+	var logSeq uint64
+	fresh := false
+	if cfg.ChangeLog {
+		logSeq, fresh, err = db.openLog(os.FileMode(cfg.FileMode))
+		if err != nil {
+			db.closeFiles()
+			return nil, err
+		}
+	}
+	// records not written to change log
+	var lost []*txRecord
+	apply := func(t uint8, key []byte, cmd *Cmd) {
+		db.applyRecord(t, key, cmd)
+		if base := t &^ recInBucket; db.fl != nil && !fresh && (base == recSet || base == recDelete) && cmd.Rev > logSeq {
+			lost = append(lost, &txRecord{t: t, key: key, cmd: cmd})
+		}
+	}
 	//read keys
-	buf := new(bytes.Buffer)
 	b, err := io.ReadAll(db.fk)
 	if err != nil {
+		db.closeFiles()
 		return nil, err
 	}
-	buf.Write(b)
 	var readSeek uint32
-	for buf.Len() > 0 {
-		_ = uint8(buf.Next(1)[0]) //format version
-		t := uint8(buf.Next(1)[0])
-		seek := binary.BigEndian.Uint32(buf.Next(4))
-		size := binary.BigEndian.Uint32(buf.Next(4))
-		_ = buf.Next(4) //time
-		sizeKey := int(binary.BigEndian.Uint16(buf.Next(2)))
-		key := buf.Next(sizeKey)
-		strkey := string(key)
-		cmd := &Cmd{
-			Seek:    seek,
-			Size:    size,
-			KeySeek: readSeek,
-		}
-		if db.storemode == 2 {
-			cmd.Val = make([]byte, size)
-			_, _ = db.fv.ReadAt(cmd.Val, int64(seek))
-		}
-		readSeek += uint32(16 + sizeKey)
-		switch t {
-		case 0:
-			if _, exists := db.vals[strkey]; !exists {
-				//write new key at keys store
-				db.appendKey(key)
+	// records of not committed transaction
+	var frame []*txRecord
+	var frameSeek uint32
+	inFrame := false
+	cmpName := false
+	// key encoding stored in index, -1 if not stored
+	keyEnc := -1
+	syncing := false
+	for int(readSeek) < len(b) {
+		t, key, cmd, n, err := decodeKey(b[readSeek:])
+		if err != nil {
+			db.closeFiles()
+			return nil, err
+		}
+		if n == 0 {
+			// torn record at the end of file
+			break
+		}
+		cmd.KeySeek = readSeek
+		if base := t &^ recInBucket; base == recSet || base == recDelete || t == recSyncEnd {
+			if cmd.ver == recVersion0 {
+				db.rev++
+				cmd.Rev = db.rev
 			}
-			db.vals[strkey] = cmd
-		case 1:
-			delete(db.vals, strkey)
-			db.deleteFromKeys(key)
+			db.rev = max(db.rev, cmd.Rev)
+		}
+		if db.storemode == 2 && t&^recInBucket == recSet {
+			cmd.Val = make([]byte, cmd.Size)
+			_, _ = db.fv.ReadAt(cmd.Val, int64(cmd.Seek))
 		}
+		switch {
+		case t == recComparator:
+			if string(key) != db.cmp.Name() {
+				db.closeFiles()
+				return nil, ErrComparator
+			}
+			cmpName = true
+		case t == recSyncBegin:
+			syncing = true
+		case t == recSyncEnd:
+			syncing = false
+		case t == recBegin:
+			inFrame = true
+			frameSeek = readSeek
+			frame = frame[:0]
+		case t == recCommit:
+			if int(readSeek)+n == len(b) && !db.frameValid(frame, cmd.flags) {
+				// values of last frame are torn by crash - rollback
+				break
+			}
+			for _, r := range frame {
+				if r.t == recKeyEncoding {
+					// changed by Rekey
+					keyEnc = int(r.cmd.Size)
+					continue
+				}
+				apply(r.t, r.key, r.cmd)
+			}
+			inFrame = false
+		case inFrame:
+			frame = append(frame, &txRecord{t: t, key: key, cmd: cmd})
+		case t == recKeyEncoding:
+			keyEnc = int(cmd.Size)
+		default:
+			apply(t, key, cmd)
+		}
+		readSeek += uint32(n)
+	}
+	if inFrame {
+		// transaction was not committed - rollback
+		readSeek = frameSeek
+	}
+	if syncing {
+		// snapshot from leader is not complete, follower must get new snapshot
+		db.rev = 0
+	}
+	if int64(readSeek) < int64(len(b)) {
+		err = db.fk.Truncate(int64(readSeek))
+		if err != nil {
+			db.closeFiles()
+			return nil, err
+		}
+	}
+	if !cmpName && (readSeek == 0 || cfg.Comparator != nil) {
+		// store comparator name for new db or db opened first time with comparator
+		_, err = writeKey(db.fk, recComparator, 0, 0, 0, 0, 0, 0, []byte(db.cmp.Name()), -1)
+		if err != nil {
+			db.closeFiles()
+			return nil, err
+		}
+	}
+	storedEnc := keyEnc >= 0
+	if !storedEnc && readSeek > 0 {
+		// db created before key encoding was stored
+		keyEnc = KeyBinary
+	}
+	if keyEnc >= 0 && keyEnc != cfg.KeyEncoding {
+		db.closeFiles()
+		return nil, ErrKeyEncoding
+	}
+	if !storedEnc {
+		// store key encoding of new db or db created before key encoding was stored
+		_, err = writeKey(db.fk, recKeyEncoding, 0, uint32(cfg.KeyEncoding), 0, 0, 0, 0, nil, -1)
+		if err != nil {
+			db.closeFiles()
+			return nil, err
+		}
+	}
+	if fresh {
+		// changes before open are not logged
+		err = db.writeLog(recBegin, nil, nil, 0, 0, 0, db.rev)
+	} else if db.fl != nil {
+		err = db.recoverLog(lost)
+	}
+	if err != nil {
+		db.closeFiles()
+		return nil, err
 	}
 
 	if cfg.SyncInterval > 0 {
@@ -149,6 +333,70 @@ func newDB(f string, cfg *Config) (*DB, error) {
 	return db, err
 }
 
+// frameValid return true if crc32 of values of frame records is sum,
+// sum 0 is not checked (frame written without crc)
+func (db *DB) frameValid(frame []*txRecord, sum uint32) bool {
+	if sum == 0 {
+		return true
+	}
+	h := crc32.NewIEEE()
+	for _, r := range frame {
+		if r.t&^recInBucket != recSet {
+			continue
+		}
+		v, err := db.readVal(r.cmd)
+		if err != nil {
+			return false
+		}
+		h.Write(v)
+	}
+	return h.Sum32() == sum
+}
+
+// closeFiles close files of db opened by newDB
+func (db *DB) closeFiles() {
+	for _, f := range []*os.File{db.fv, db.fk, db.fl} {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+// applyRecord apply index record to keys and vals
+func (db *DB) applyRecord(t uint8, key []byte, cmd *Cmd) {
+	if t&recInBucket != 0 {
+		if len(key) == 0 || len(key) < 1+int(key[0]) {
+			return
+		}
+		name := string(key[1 : 1+key[0]])
+		db.newBucket(name).applyRecord(t&^recInBucket, key[1+key[0]:], cmd)
+		return
+	}
+	strkey := string(key)
+	switch t {
+	case recSet:
+		if _, exists := db.vals[strkey]; !exists {
+			//write new key at keys store
+			db.appendKey(key)
+		}
+		db.vals[strkey] = cmd
+	case recDelete:
+		delete(db.vals, strkey)
+		db.deleteFromKeys(key)
+	case recIndex, recUnindex:
+		db.applyIndex(t, key)
+	case recBucket:
+		db.newBucket(strkey)
+	case recDropBucket:
+		db.dropBucket(strkey)
+	case recKeyEncoding:
+		db.keyEncoding = int(cmd.Size)
+		for _, b := range db.buckets {
+			b.keyEncoding = db.keyEncoding
+		}
+	}
+}
+
 // backgroundManager runs continuously in the background and performs various
 // operations such as syncing to disk.
 func (db *DB) backgroundManager(interval int) {
@@ -174,50 +422,57 @@ func (db *DB) backgroundManager(interval int) {
 func (db *DB) appendKey(b []byte) {
 	//log.Println("append")
 	db.keys = append(db.keys, b)
+	db.unsorted.Store(true)
 }
 
 // deleteFromKeys delete key from slice keys
 func (db *DB) deleteFromKeys(b []byte) {
-	found := db.found(b, true)
-	if found < len(db.keys) {
-		if bytes.Equal(db.keys[found], b) {
-			db.keys = append(db.keys[:found], db.keys[found+1:]...)
-		}
+	db.sort()
+	if found, ok := db.indexOf(b); ok {
+		db.keys = append(db.keys[:found], db.keys[found+1:]...)
 	}
 }
 
 func (db *DB) sort() {
-	if !sort.SliceIsSorted(db.keys, db.lessBinary) {
-		//log.Println("sort")
-		sort.Slice(db.keys, db.lessBinary)
+	if !db.unsorted.Load() {
+		return
+	}
+	db.sortMu.Lock()
+	defer db.sortMu.Unlock()
+	if db.unsorted.Load() {
+		if !sort.SliceIsSorted(db.keys, db.lessBinary) {
+			//log.Println("sort")
+			sort.Slice(db.keys, db.lessBinary)
+		}
+		db.unsorted.Store(false)
 	}
 }
 
 func (db *DB) lessBinary(i, j int) bool {
-	return bytes.Compare(db.keys[i], db.keys[j]) <= 0
+	return db.compare(db.keys[i], db.keys[j]) <= 0
 }
 
 // found return binary search result with sort order
 func (db *DB) found(b []byte, _ bool) int {
 	db.sort()
 	//if asc {
-	return sort.Search(len(db.keys), func(i int) bool {
-		return bytes.Compare(db.keys[i], b) >= 0
-	})
+	return db.seekGE(b)
 	//}
 	//return sort.Search(len(db.keys), func(i int) bool {
 	//	return bytes.Compare(db.keys[i], b) <= 0
 	//})
 }
 
-func writeKeyVal(fk, fv *os.File, readKey, writeVal []byte, exists bool, oldCmd *Cmd) (cmd *Cmd, err error) {
+// writeKeyVal store value and key address
+// if reuse == false old value will never be overwritten in place
+func writeKeyVal(fk, fv *os.File, t uint8, readKey, writeVal []byte, exists, reuse bool, oldCmd *Cmd, rev uint64, codec uint8, flags, expire uint32) (cmd *Cmd, err error) {
 	var seek, newSeek int64
-	cmd = &Cmd{Size: uint32(len(writeVal))}
+	cmd = &Cmd{Size: uint32(len(writeVal)), Rev: rev, ver: recVersion, codec: codec, flags: flags, expire: expire}
 	if exists {
 		// key exists
 		cmd.Seek = oldCmd.Seek
 		cmd.KeySeek = oldCmd.KeySeek
-		if oldCmd.Size >= uint32(len(writeVal)) {
+		if reuse && oldCmd.Size >= uint32(len(writeVal)) {
 			//write at old seek new value
 			_, _, err = writeAtPos(fv, writeVal, int64(oldCmd.Seek))
 		} else {
@@ -225,9 +480,14 @@ func writeKeyVal(fk, fv *os.File, readKey, writeVal []byte, exists bool, oldCmd
 			seek, _, err = writeAtPos(fv, writeVal, int64(-1))
 			cmd.Seek = uint32(seek)
 		}
+		keySeek := int64(cmd.KeySeek)
+		if oldCmd.ver != recVersion {
+			// old record has another size - append new record
+			keySeek = -1
+		}
 		if err == nil {
 			// if no error - store key at KeySeek
-			newSeek, err = writeKey(fk, 0, cmd.Seek, cmd.Size, []byte(readKey), int64(cmd.KeySeek))
+			newSeek, err = writeKey(fk, t, cmd.Seek, cmd.Size, rev, codec, flags, expire, []byte(readKey), keySeek)
 			cmd.KeySeek = uint32(newSeek)
 		}
 	} else {
@@ -236,7 +496,7 @@ func writeKeyVal(fk, fv *os.File, readKey, writeVal []byte, exists bool, oldCmd
 		seek, _, err = writeAtPos(fv, writeVal, int64(-1))
 		cmd.Seek = uint32(seek)
 		if err == nil {
-			newSeek, err = writeKey(fk, 0, cmd.Seek, cmd.Size, []byte(readKey), -1)
+			newSeek, err = writeKey(fk, t, cmd.Seek, cmd.Size, rev, codec, flags, expire, []byte(readKey), -1)
 			cmd.KeySeek = uint32(newSeek)
 		}
 	}
@@ -260,28 +520,97 @@ func writeAtPos(f *os.File, b []byte, pos int64) (seek int64, n int, err error)
 }
 
 // writeKey create buffer and store key with val address and size
-func writeKey(fk *os.File, t uint8, seek, size uint32, key []byte, keySeek int64) (newSeek int64, err error) {
+func writeKey(fk *os.File, t uint8, seek, size uint32, rev uint64, codec uint8, flags, expire uint32, key []byte, keySeek int64) (newSeek int64, err error) {
 	//get buf from pool
 	buf := new(bytes.Buffer)
 	buf.Reset()
-	buf.Grow(16 + len(key))
+	encodeKey(buf, t, seek, size, rev, codec, flags, expire, key)
+
+	if keySeek < 0 {
+		newSeek, _, err = writeAtPos(fk, buf.Bytes(), int64(-1))
+	} else {
+		newSeek, _, err = writeAtPos(fk, buf.Bytes(), int64(keySeek))
+	}
+
+	return newSeek, err
+}
 
-	//encode
-	_ = binary.Write(buf, binary.BigEndian, uint8(0))                  //1byte version
-	_ = binary.Write(buf, binary.BigEndian, t)                         //1byte command code(0-set,1-delete)
+// encodeKey write index record to buffer
+func encodeKey(buf *bytes.Buffer, t uint8, seek, size uint32, rev uint64, codec uint8, flags, expire uint32, key []byte) {
+	buf.Grow(33 + len(key))
+	_ = binary.Write(buf, binary.BigEndian, recVersion)                //1byte version
+	_ = binary.Write(buf, binary.BigEndian, t)                         //1byte command code(0-set,1-delete,2-begin,3-commit)
 	_ = binary.Write(buf, binary.BigEndian, seek)                      //4byte seek
 	_ = binary.Write(buf, binary.BigEndian, size)                      //4byte size
 	_ = binary.Write(buf, binary.BigEndian, uint32(time.Now().Unix())) //4byte timestamp
+	_ = binary.Write(buf, binary.BigEndian, rev)                       //8byte revision
+	_ = binary.Write(buf, binary.BigEndian, codec)                     //1byte codec id
+	_ = binary.Write(buf, binary.BigEndian, flags)                     //4byte flags
+	_ = binary.Write(buf, binary.BigEndian, expire)                    //4byte expire time
 	_ = binary.Write(buf, binary.BigEndian, uint16(len(key)))          //2byte key size
 	_, _ = buf.Write(key)                                              //key
+}
 
-	if keySeek < 0 {
-		newSeek, _, err = writeAtPos(fk, buf.Bytes(), int64(-1))
-	} else {
-		newSeek, _, err = writeAtPos(fk, buf.Bytes(), int64(keySeek))
+// headerSize return size of index record without key or 0 for unknown version
+func headerSize(ver uint8) int {
+	switch ver {
+	case recVersion0:
+		return 16
+	case recVersion1:
+		return 24
+	case recVersion2:
+		return 25
+	case recVersion3:
+		return 33
 	}
+	return 0
+}
 
-	return newSeek, err
+// decodeKey read index record from b
+// return n == 0 if b don't contain full record
+func decodeKey(b []byte) (t uint8, key []byte, cmd *Cmd, n int, err error) {
+	if len(b) < 16 {
+		return 0, nil, nil, 0, nil
+	}
+	cmd = &Cmd{ver: b[0]}
+	t = b[1]
+	cmd.Seek = binary.BigEndian.Uint32(b[2:6])
+	cmd.Size = binary.BigEndian.Uint32(b[6:10])
+	//b[10:14] - time
+	pos := 14
+	switch cmd.ver {
+	case recVersion0:
+	case recVersion1:
+		if len(b) < 24 {
+			return 0, nil, nil, 0, nil
+		}
+		cmd.Rev = binary.BigEndian.Uint64(b[14:22])
+		pos = 22
+	case recVersion2:
+		if len(b) < 25 {
+			return 0, nil, nil, 0, nil
+		}
+		cmd.Rev = binary.BigEndian.Uint64(b[14:22])
+		cmd.codec = b[22]
+		pos = 23
+	case recVersion3:
+		if len(b) < 33 {
+			return 0, nil, nil, 0, nil
+		}
+		cmd.Rev = binary.BigEndian.Uint64(b[14:22])
+		cmd.codec = b[22]
+		cmd.flags = binary.BigEndian.Uint32(b[23:27])
+		cmd.expire = binary.BigEndian.Uint32(b[27:31])
+		pos = 31
+	default:
+		return 0, nil, nil, 0, ErrFormat
+	}
+	sizeKey := int(binary.BigEndian.Uint16(b[pos : pos+2]))
+	n = pos + 2 + sizeKey
+	if len(b) < n {
+		return 0, nil, nil, 0, nil
+	}
+	return t, b[pos+2 : n], cmd, n, nil
 }
 
 // findKey return index of first key in ascending mode
@@ -295,22 +624,46 @@ func (db *DB) findKey(key any, asc bool) (int, error) {
 		}
 		return len(db.keys) - 1, ErrKeyNotFound
 	}
-	k, err := KeyToBinary(key)
+	k, err := db.keyToBinary(key)
 	if err != nil {
 		return -1, err
 	}
-	found := db.found(k, asc)
-	//log.Println("found", found)
-	// check found
-	if found >= len(db.keys) {
-		return -1, ErrKeyNotFound
-	}
-	if !bytes.Equal(db.keys[found], k) {
+	db.sort()
+	found, ok := db.indexOf(k)
+	if !ok {
 		return -1, ErrKeyNotFound
 	}
 	return found, nil
 }
 
+// keysAfter return up to n sorted keys after from (from not included)
+// if from is nil - start from the first (last in descending mode) key
+// if n == 0 return all keys
+func (db *DB) keysAfter(from []byte, n int, asc bool) [][]byte {
+	db.RLock()
+	defer db.RUnlock()
+	db.sort()
+	arr := make([][]byte, 0)
+	if asc {
+		start := 0
+		if from != nil {
+			start = db.seekGT(from)
+		}
+		for i := start; i < len(db.keys) && (n == 0 || len(arr) < n); i++ {
+			arr = append(arr, db.keys[i])
+		}
+		return arr
+	}
+	start := len(db.keys) - 1
+	if from != nil {
+		start = db.found(from, asc) - 1
+	}
+	for i := start; i >= 0 && (n == 0 || len(arr) < n); i-- {
+		arr = append(arr, db.keys[i])
+	}
+	return arr
+}
+
 // startFrom return is a start from b in binary
 func startFrom(a, b []byte) bool {
 	if a == nil || b == nil {
@@ -325,9 +678,7 @@ func startFrom(a, b []byte) bool {
 func (db *DB) foundPref(b []byte, asc bool) int {
 	db.sort()
 	if asc {
-		return sort.Search(len(db.keys), func(i int) bool {
-			return bytes.Compare(db.keys[i], b) >= 0
-		})
+		return db.prefixFirst(b)
 	}
 	var j int
 	for j = len(db.keys) - 1; j >= 0; j-- {
@@ -0,0 +1,321 @@
+// Package fudgehttp serve fudge databases over HTTP.
+//
+//	GET    /db/{name}/keys/{key}  value, raw bytes or JSON
+//	PUT    /db/{name}/keys/{key}  store body as value
+//	DELETE /db/{name}/keys/{key}  delete key
+//	GET    /db/{name}/keys?prefix=&from=&limit=&offset=&order=  JSON array of keys
+//
+// Keys are listed after from in ascending order or before from in descending order,
+// from don't need to exist in db. Body of PUT is limited by MaxValueSize.
+//
+// Database name is file name in directory of handler of letters, digits, '_' and '-',
+// databases are opened with fudge.Open.
+// Values are raw bytes by default. With ?format=json or Accept: application/json
+// GET decode value with codec of db and render it as JSON.
+// PUT with Content-Type: application/json encode JSON body with codec of db.
+package fudgehttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gnuos/fudge"
+)
+
+// MaxValueSize is max size of PUT body, greater body is rejected with 413
+var MaxValueSize int64 = 64 << 20
+
+// Handler serve databases in dir
+type Handler struct {
+	dir string
+	cfg *fudge.Config
+	mux *http.ServeMux
+
+	mu  sync.Mutex
+	dbs map[string]*fudge.DB
+}
+
+// NewHandler return handler of databases in dir opened with cfg
+func NewHandler(dir string, cfg *fudge.Config) *Handler {
+	h := &Handler{dir: dir, cfg: cfg, mux: http.NewServeMux(), dbs: make(map[string]*fudge.DB)}
+	h.mux.HandleFunc("GET /db/{name}/keys/{key}", h.get)
+	h.mux.HandleFunc("PUT /db/{name}/keys/{key}", h.put)
+	h.mux.HandleFunc("DELETE /db/{name}/keys/{key}", h.delete)
+	h.mux.HandleFunc("GET /db/{name}/keys", h.keys)
+	return h
+}
+
+// ServeHTTP implement http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// open return db of request, write error if any
+func (h *Handler) open(w http.ResponseWriter, r *http.Request) *fudge.DB {
+	name := r.PathValue("name")
+	if !validName(name) {
+		http.Error(w, "bad db name", http.StatusBadRequest)
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if db, ok := h.dbs[name]; ok {
+		return db
+	}
+	db, err := fudge.Open(filepath.Join(h.dir, name), h.cfg)
+	if err != nil {
+		writeError(w, err)
+		return nil
+	}
+	h.dbs[name] = db
+	return db
+}
+
+// validName return true for db name of letters, digits, '_' and '-',
+// so name can't be path or index and log file of another db
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	db := h.open(w, r)
+	if db == nil {
+		return
+	}
+	key := []byte(r.PathValue("key"))
+	if !wantJSON(r) {
+		var v []byte
+		if err := db.Get(key, &v); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(v)
+		return
+	}
+	var v any
+	if err := db.Get(key, &v); err != nil {
+		if err != fudge.ErrKeyNotFound {
+			err = fmt.Errorf("%w: %v", errDecode, err)
+		}
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, jsonValue(v))
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request) {
+	db := h.open(w, r)
+	if db == nil {
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValueSize))
+	if err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(*http.MaxBytesError); ok {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	key := []byte(r.PathValue("key"))
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "application/json" {
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err = dec.Decode(&v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = db.Set(key, fromJSON(v))
+	} else {
+		err = db.Set(key, body)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	db := h.open(w, r)
+	if db == nil {
+		return
+	}
+	if err := db.Delete([]byte(r.PathValue("key"))); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keys list keys, prefix and from can't be used together
+func (h *Handler) keys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := intParam(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "bad limit", http.StatusBadRequest)
+		return
+	}
+	offset, err := intParam(q.Get("offset"))
+	if err != nil {
+		http.Error(w, "bad offset", http.StatusBadRequest)
+		return
+	}
+	asc := true
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		asc = false
+	default:
+		http.Error(w, "bad order", http.StatusBadRequest)
+		return
+	}
+	prefix, from := q.Get("prefix"), q.Get("from")
+	if prefix != "" && from != "" {
+		http.Error(w, "prefix and from can't be used together", http.StatusBadRequest)
+		return
+	}
+	db := h.open(w, r)
+	if db == nil {
+		return
+	}
+	var keys [][]byte
+	switch {
+	case prefix != "":
+		keys, err = db.KeysByPrefix([]byte(prefix), limit, offset, asc)
+	case from != "" && asc:
+		keys, err = db.KeysRange([]byte(from), nil, &fudge.RangeOptions{ExcludeStart: true, Limit: limit, Offset: offset})
+	case from != "":
+		keys, err = db.KeysRange(nil, []byte(from), &fudge.RangeOptions{Limit: limit, Offset: offset, Desc: true})
+	default:
+		keys, err = db.Keys(nil, limit, offset, asc)
+	}
+	if err != nil && err != fudge.ErrKeyNotFound {
+		writeError(w, err)
+		return
+	}
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = string(k)
+	}
+	writeJSON(w, res)
+}
+
+// errDecode - value can't be decoded with codec of db
+var errDecode = errors.New("value is not decodable")
+
+// writeError write error with status of error
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case err == fudge.ErrKeyNotFound:
+		status = http.StatusNotFound
+	case err == fudge.ErrReadOnly:
+		status = http.StatusForbidden
+	case errors.Is(err, errDecode):
+		status = http.StatusUnprocessableEntity
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: %v", errDecode, err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// wantJSON return true if client ask for JSON value
+func wantJSON(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f == "json"
+	}
+	for _, a := range strings.Split(r.Header.Get("Accept"), ",") {
+		if ct, _, _ := mime.ParseMediaType(a); ct == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValue convert decoded value to value accepted by json.Marshal,
+// CBOR maps may have keys of any type
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case map[string]any:
+		for k, e := range v {
+			v[k] = jsonValue(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+		return v
+	}
+	return v
+}
+
+// fromJSON convert numbers of decoded JSON to int64 or float64,
+// so integers are stored as integers
+func fromJSON(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = fromJSON(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = fromJSON(e)
+		}
+	}
+	return v
+}
+
+// intParam parse optional non-negative int parameter
+func intParam(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		err = strconv.ErrRange
+	}
+	return n, err
+}
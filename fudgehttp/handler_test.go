@@ -0,0 +1,83 @@
+package fudgehttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gnuos/fudge"
+)
+
+func TestHandler(t *testing.T) {
+	defer fudge.CloseAll()
+	dir := t.TempDir()
+	h := NewHandler(dir, &fudge.Config{})
+	defer func(n int64) { MaxValueSize = n }(MaxValueSize)
+	MaxValueSize = 64
+	db, err := fudge.Open(dir+"/users", &fudge.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type User struct {
+		Name string
+		Age  int
+	}
+	db.Set("alice", User{Name: "Alice", Age: 30})
+
+	tests := []struct {
+		method, url, ctype, accept, body string
+		status                           int
+		want                             string
+	}{
+		{"PUT", "/db/users/keys/bob", "", "", "raw bytes", 204, ""},
+		{"PUT", "/db/users/keys/carol", "application/json", "", `{"Name":"Carol","Age":25}`, 204, ""},
+		{"PUT", "/db/users/keys/dave", "application/json", "", `{bad`, 400, ""},
+		{"GET", "/db/users/keys/bob", "", "", "", 200, "raw bytes"},
+		{"GET", "/db/users/keys/alice?format=json", "", "", "", 200, `{"Age":30,"Name":"Alice"}`},
+		{"GET", "/db/users/keys/carol", "", "application/json", "", 200, `{"Age":25,"Name":"Carol"}`},
+		{"GET", "/db/users/keys/bob?format=json", "", "", "", 422, ""},
+		{"GET", "/db/users/keys/nope", "", "", "", 404, ""},
+		{"GET", "/db/users/keys", "", "", "", 200, `["alice","bob","carol"]`},
+		{"GET", "/db/users/keys?order=desc&limit=2", "", "", "", 200, `["carol","bob"]`},
+		{"GET", "/db/users/keys?prefix=c", "", "", "", 200, `["carol"]`},
+		{"GET", "/db/users/keys?prefix=x", "", "", "", 200, `[]`},
+		{"GET", "/db/users/keys?from=alice&offset=1", "", "", "", 200, `["carol"]`},
+		{"GET", "/db/users/keys?from=b", "", "", "", 200, `["bob","carol"]`},
+		{"GET", "/db/users/keys?from=bz&order=desc", "", "", "", 200, `["bob","alice"]`},
+		{"PUT", "/db/users/keys/big", "", "", strings.Repeat("x", 65), 413, ""},
+		{"GET", "/db/users/keys?limit=-1", "", "", "", 400, ""},
+		{"DELETE", "/db/users/keys/bob", "", "", "", 204, ""},
+		{"DELETE", "/db/users/keys/bob", "", "", "", 404, ""},
+		{"GET", "/db/users/keys", "", "", "", 200, `["alice","carol"]`},
+		{"GET", "/db/%2e%2e/keys", "", "", "", 400, ""},
+		{"PUT", "/db/users.idx/keys/x", "", "", strings.Repeat("x", 20), 400, ""},
+		{"GET", "/db/users.log/keys", "", "", "", 400, ""},
+		{"GET", "/db/users/keys", "", "", "", 200, `["alice","carol"]`},
+		{"GET", "/db/empty/keys", "", "", "", 200, `[]`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		if tt.ctype != "" {
+			r.Header.Set("Content-Type", tt.ctype)
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d: %s", tt.method, tt.url, w.Code, tt.status, w.Body)
+			continue
+		}
+		body, _ := io.ReadAll(w.Body)
+		if tt.status == http.StatusOK && string(body) != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.method, tt.url, body, tt.want)
+		}
+	}
+	var u User
+	if err = db.Get("carol", &u); err != nil || u.Age != 25 {
+		t.Error("json value must be stored with codec of db", u, err)
+	}
+}
@@ -0,0 +1,292 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"iter"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrIndexNotFound - index not created
+	ErrIndexNotFound = errors.New("error: index not found")
+	// ErrIndexExists - index already created
+	ErrIndexExists = errors.New("error: index exists")
+)
+
+// IndexFunc return index values for key value, value is in binary form.
+// Use ValFromBinary (or Codec.Unmarshal) to decode value:
+//
+//	func(key, value []byte) [][]byte {
+//		var u User
+//		if fudge.ValFromBinary(value, &u) != nil {
+//			return nil
+//		}
+//		return [][]byte{[]byte(u.Name)}
+//	}
+type IndexFunc func(key, value []byte) [][]byte
+
+// index hold entries of secondary index.
+// Entry is packed Tuple{name, value, key}, so entries are ordered by value.
+type index struct {
+	fn      IndexFunc
+	entries map[string]struct{}
+	list    [][]byte   // sorted entries, nil if changed
+	mu      sync.Mutex // guard list built by readers under read lock of db
+}
+
+// CreateIndex create secondary index or attach fn to index stored in db files.
+// Index entries are stored in index file and updated with every Set/Delete
+// in one transaction, so every write of indexed db is framed and synced.
+// Call CreateIndex after every Open before writes,
+// if db was changed without it call RebuildIndex.
+func (db *DB) CreateIndex(name string, fn IndexFunc) error {
+	db.Lock()
+	defer db.Unlock()
+	ix, ok := db.indexes[name]
+	if ok && ix.fn != nil {
+		return ErrIndexExists
+	}
+	if ok {
+		ix.fn = fn
+		return nil
+	}
+	db.indexes[name] = &index{fn: fn, entries: make(map[string]struct{})}
+	return db.rebuildIndex(name)
+}
+
+// RebuildIndex recompute all entries of index
+func (db *DB) RebuildIndex(name string) error {
+	db.Lock()
+	defer db.Unlock()
+	if ix, ok := db.indexes[name]; !ok || ix.fn == nil {
+		return ErrIndexNotFound
+	}
+	return db.rebuildIndex(name)
+}
+
+// DropIndex remove index and all its entries
+func (db *DB) DropIndex(name string) error {
+	db.Lock()
+	defer db.Unlock()
+	ix, ok := db.indexes[name]
+	if !ok {
+		return ErrIndexNotFound
+	}
+	ops := make([]*txRecord, 0, len(ix.entries))
+	for e := range ix.entries {
+		ops = append(ops, &txRecord{t: recUnindex, key: []byte(e)})
+	}
+	err := db.commit(ops)
+	if err != nil {
+		return err
+	}
+	delete(db.indexes, name)
+	return nil
+}
+
+// Lookup return keys with index value in ascending order
+func (db *DB) Lookup(name string, value any) ([][]byte, error) {
+	v, err := db.keyToBinary(value)
+	if err != nil {
+		return nil, err
+	}
+	_, keys, err := db.indexScan(name, v, nil, true)
+	return keys, err
+}
+
+// IndexScan return iterator over index values and keys with lo <= value < hi,
+// ordered by value, nil bound means no bound. Not existing index is empty.
+//
+//	for name, key := range db.IndexScan("name", "A", "C") {
+//		...
+//	}
+func (db *DB) IndexScan(name string, lo, hi any) iter.Seq2[[]byte, []byte] {
+	var l, h []byte
+	var err error
+	if lo != nil {
+		if l, err = db.keyToBinary(lo); err != nil {
+			return func(yield func([]byte, []byte) bool) {}
+		}
+	}
+	if hi != nil {
+		if h, err = db.keyToBinary(hi); err != nil {
+			return func(yield func([]byte, []byte) bool) {}
+		}
+	}
+	return func(yield func([]byte, []byte) bool) {
+		vals, keys, _ := db.indexScan(name, l, h, false)
+		for i := range keys {
+			if !yield(vals[i], keys[i]) {
+				return
+			}
+		}
+	}
+}
+
+// indexScan return values and keys of entries with lo <= value < hi,
+// if exact - entries with value lo
+func (db *DB) indexScan(name string, lo, hi []byte, exact bool) ([][]byte, [][]byte, error) {
+	db.RLock()
+	defer db.RUnlock()
+	vals := make([][]byte, 0)
+	keys := make([][]byte, 0)
+	ix, ok := db.indexes[name]
+	if !ok {
+		return vals, keys, ErrIndexNotFound
+	}
+	list := ix.sorted()
+	search := func(b []byte) int {
+		return sort.Search(len(list), func(i int) bool {
+			return bytes.Compare(list[i], b) >= 0
+		})
+	}
+	i, end := 0, len(list)
+	if lo != nil {
+		i = search(indexEntry(name, lo, nil))
+	}
+	if hi != nil {
+		end = search(indexEntry(name, hi, nil))
+	}
+	for start := indexEntry(name, lo, nil); i < end; i++ {
+		// packed value is terminated, so entries of value start with same bytes
+		if exact && !bytes.HasPrefix(list[i], start) {
+			break
+		}
+		var t Tuple
+		if t.Unpack(list[i]) != nil || len(t) != 3 {
+			continue
+		}
+		vals = append(vals, t[1].([]byte))
+		keys = append(keys, t[2].([]byte))
+	}
+	return vals, keys, nil
+}
+
+// rebuildIndex commit difference between stored and computed entries, db must be locked
+func (db *DB) rebuildIndex(name string) error {
+	ix := db.indexes[name]
+	want := make(map[string]struct{})
+	for k, cmd := range db.vals {
+		v, err := db.readVal(cmd)
+		if err != nil {
+			return err
+		}
+		for _, iv := range ix.fn([]byte(k), v) {
+			want[string(indexEntry(name, iv, []byte(k)))] = struct{}{}
+		}
+	}
+	ops := make([]*txRecord, 0)
+	for e := range ix.entries {
+		if _, ok := want[e]; !ok {
+			ops = append(ops, &txRecord{t: recUnindex, key: []byte(e)})
+		}
+	}
+	for e := range want {
+		if _, ok := ix.entries[e]; !ok {
+			ops = append(ops, &txRecord{t: recIndex, key: []byte(e)})
+		}
+	}
+	return db.commit(ops)
+}
+
+// indexed return true if any index must be updated on write
+func (db *DB) indexed() bool {
+	for _, ix := range db.indexes {
+		if ix.fn != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOps return index records for set and delete records, db must be locked
+func (db *DB) indexOps(ops []*txRecord) ([]*txRecord, error) {
+	res := make([]*txRecord, 0)
+	for name, ix := range db.indexes {
+		if ix.fn == nil {
+			continue
+		}
+		for _, r := range ops {
+			if r.t != recSet && r.t != recDelete {
+				continue
+			}
+			old := make(map[string]struct{})
+			if cmd, ok := db.vals[string(r.key)]; ok {
+				v, err := db.readVal(cmd)
+				if err != nil {
+					return nil, err
+				}
+				for _, iv := range ix.fn(r.key, v) {
+					old[string(indexEntry(name, iv, r.key))] = struct{}{}
+				}
+			}
+			cur := make(map[string]struct{})
+			if r.t == recSet {
+				for _, iv := range ix.fn(r.key, r.val) {
+					cur[string(indexEntry(name, iv, r.key))] = struct{}{}
+				}
+			}
+			for e := range old {
+				if _, ok := cur[e]; !ok {
+					res = append(res, &txRecord{t: recUnindex, key: []byte(e)})
+				}
+			}
+			for e := range cur {
+				if _, ok := old[e]; !ok {
+					res = append(res, &txRecord{t: recIndex, key: []byte(e)})
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// applyIndex apply index record
+func (db *DB) applyIndex(t uint8, entry []byte) {
+	if len(entry) == 0 || entry[0] != tupleString {
+		return
+	}
+	name, _, err := readTupleBytes(entry[1:])
+	if err != nil {
+		return
+	}
+	ix, ok := db.indexes[string(name)]
+	if !ok {
+		ix = &index{entries: make(map[string]struct{})}
+		db.indexes[string(name)] = ix
+	}
+	if t == recIndex {
+		ix.entries[string(entry)] = struct{}{}
+	} else {
+		delete(ix.entries, string(entry))
+	}
+	ix.list = nil
+}
+
+// sorted return sorted entries
+func (ix *index) sorted() [][]byte {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.list == nil {
+		ix.list = make([][]byte, 0, len(ix.entries))
+		for e := range ix.entries {
+			ix.list = append(ix.list, []byte(e))
+		}
+		sort.Slice(ix.list, func(i, j int) bool {
+			return bytes.Compare(ix.list[i], ix.list[j]) < 0
+		})
+	}
+	return ix.list
+}
+
+// indexEntry return entry of index, without key if key is nil
+func indexEntry(name string, value, key []byte) []byte {
+	t := Tuple{name, value}
+	if key != nil {
+		t = append(t, key)
+	}
+	b, _ := t.Pack()
+	return b
+}
@@ -0,0 +1,125 @@
+package fudge
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	type user struct {
+		Id   int
+		Name string
+	}
+	byName := func(key, value []byte) [][]byte {
+		var u user
+		if ValFromBinary(value, &u) != nil {
+			return nil
+		}
+		return [][]byte{[]byte(u.Name)}
+	}
+	f := "test/index"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set(1, user{1, "Bob"})
+	err = db.CreateIndex("name", byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateIndex("name", byName); err != ErrIndexExists {
+		t.Error("must be ErrIndexExists", err)
+	}
+	db.Set(2, user{2, "Alice"})
+	db.Set(3, user{3, "Carol"})
+	db.Set(4, user{4, "Alice"})
+	keys, _ := db.Lookup("name", "Alice")
+	if len(keys) != 2 {
+		t.Error("must be 2 Alice", keys)
+	}
+	db.Set(4, user{4, "Dave"})
+	db.Delete(3)
+	keys, _ = db.Lookup("name", "Carol")
+	if len(keys) != 0 {
+		t.Error("Carol deleted", keys)
+	}
+	db.Close()
+
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.CreateIndex("name", byName)
+	keys, _ = db.Lookup("name", "Alice")
+	if len(keys) != 1 {
+		t.Error("must be 1 Alice", keys)
+	}
+	res := make([]string, 0)
+	for v := range db.IndexScan("name", "B", nil) {
+		res = append(res, string(v))
+	}
+	if len(res) != 2 || res[0] != "Bob" || res[1] != "Dave" {
+		t.Error("bad scan", res)
+	}
+	db.Close()
+
+	// write without index, then rebuild
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set(5, user{5, "Eve"})
+	if err = db.RebuildIndex("name"); err != ErrIndexNotFound {
+		t.Error("index is not attached", err)
+	}
+	db.CreateIndex("name", byName)
+	db.RebuildIndex("name")
+	keys, _ = db.Lookup("name", "Eve")
+	if len(keys) != 1 {
+		t.Error("must be 1 Eve", keys)
+	}
+	err = db.DropIndex("name")
+	if err != nil {
+		t.Error(err)
+	}
+	if _, err = db.Lookup("name", "Eve"); err != ErrIndexNotFound {
+		t.Error(err)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndexConcurrentLookup(t *testing.T) {
+	f := "test/index_concurrent"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	err = db.CreateIndex("value", func(key, value []byte) [][]byte {
+		return [][]byte{value}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		db.Set(i, i%10)
+	}
+	// sorted entries are built by first lookups under read lock
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := ValToBinary(3)
+			if keys, err := db.Lookup("value", v); err != nil || len(keys) != 10 {
+				t.Error("must be 10 keys", len(keys), err)
+			}
+		}()
+	}
+	wg.Wait()
+}
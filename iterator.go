@@ -0,0 +1,192 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrIteratorClosed - iterator used after Close
+var ErrIteratorClosed = errors.New("error: iterator closed")
+
+// IteratorOptions bound keys of iterator.
+// Nil fields are not used.
+type IteratorOptions struct {
+	Prefix []byte // only keys with prefix
+	Start  []byte // first key, included
+	End    []byte // last key, not included
+}
+
+// Iterator walk keys in ascending or descending order with constant memory.
+// Iterator don't hold db lock between moves: every move search
+// next key from current, so concurrent Set/Delete are visible.
+type Iterator struct {
+	db     *DB
+	opts   IteratorOptions
+	key    []byte
+	err    error
+	closed bool
+}
+
+// NewIterator return iterator, not positioned.
+// Call First, Last or Seek before Key/Value.
+func (db *DB) NewIterator(opts *IteratorOptions) *Iterator {
+	it := &Iterator{db: db}
+	if opts != nil {
+		it.opts = *opts
+	}
+	return it
+}
+
+// First move to first key, return false if no keys
+func (it *Iterator) First() bool {
+	return it.move(func(db *DB) int {
+		return it.lower(db, 0)
+	})
+}
+
+// Last move to last key, return false if no keys
+func (it *Iterator) Last() bool {
+	return it.move(func(db *DB) int {
+		i := len(db.keys) - 1
+		if it.opts.End != nil {
+			i = min(i, db.seekGE(it.opts.End)-1)
+		}
+		if it.opts.Prefix != nil {
+			i = min(i, db.prefixLast(it.opts.Prefix))
+		}
+		return i
+	})
+}
+
+// Seek move to first key greater or equal to key, return false if no such key
+func (it *Iterator) Seek(key any) bool {
+	k, err := it.db.keyToBinary(key)
+	if err != nil {
+		it.err = err
+		it.key = nil
+		return false
+	}
+	return it.move(func(db *DB) int {
+		return it.lower(db, db.seekGE(k))
+	})
+}
+
+// Next move to next key, return false if no more keys
+func (it *Iterator) Next() bool {
+	k := it.key
+	if k == nil {
+		return false
+	}
+	return it.move(func(db *DB) int {
+		if i, ok := db.indexOf(k); ok {
+			return i + 1
+		}
+		return db.seekGE(k)
+	})
+}
+
+// Prev move to previous key, return false if no more keys
+func (it *Iterator) Prev() bool {
+	k := it.key
+	if k == nil {
+		return false
+	}
+	return it.move(func(db *DB) int {
+		if i, ok := db.indexOf(k); ok {
+			return i - 1
+		}
+		return db.seekGE(k) - 1
+	})
+}
+
+// Valid return true if iterator positioned at key
+func (it *Iterator) Valid() bool {
+	return it.key != nil
+}
+
+// Key return current key or nil
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Value return value of current key
+// Return error if any.
+func (it *Iterator) Value(dst any) error {
+	if it.closed {
+		return ErrIteratorClosed
+	}
+	if it.key == nil {
+		return ErrKeyNotFound
+	}
+	it.db.RLock()
+	defer it.db.RUnlock()
+	return it.db.get(it.key, dst)
+}
+
+// Err return error of last Seek or error which stopped All if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close iterator
+func (it *Iterator) Close() error {
+	it.closed = true
+	it.key = nil
+	return nil
+}
+
+// move set iterator to key at index returned by find
+func (it *Iterator) move(find func(db *DB) int) bool {
+	it.key = nil
+	if it.closed {
+		it.err = ErrIteratorClosed
+		return false
+	}
+	it.err = nil
+	db := it.db
+	db.RLock()
+	defer db.RUnlock()
+	db.sort()
+	i := find(db)
+	if i >= 0 && i < len(db.keys) && it.inRange(db.keys[i]) {
+		it.key = db.keys[i]
+	}
+	return it.key != nil
+}
+
+// lower return index i moved up to lower bound of keys
+func (it *Iterator) lower(db *DB, i int) int {
+	if it.opts.Start != nil {
+		i = max(i, db.seekGE(it.opts.Start))
+	}
+	if it.opts.Prefix != nil {
+		i = max(i, db.prefixFirst(it.opts.Prefix))
+	}
+	return i
+}
+
+func (it *Iterator) inRange(k []byte) bool {
+	if it.opts.Start != nil && it.db.compare(k, it.opts.Start) < 0 {
+		return false
+	}
+	if it.opts.End != nil && it.db.compare(k, it.opts.End) >= 0 {
+		return false
+	}
+	if it.opts.Prefix != nil && !bytes.HasPrefix(k, it.opts.Prefix) {
+		return false
+	}
+	return true
+}
+
+// prefixEnd return first key after all keys with prefix
+// or nil if there is no such key
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
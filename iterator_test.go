@@ -0,0 +1,178 @@
+package fudge
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIterator(t *testing.T) {
+	f := "test/iterator"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 22; i >= 1; i-- {
+		db.Set(fmt.Sprintf("%02d", i), i)
+	}
+
+	it := db.NewIterator(nil)
+	s := ""
+	for ok := it.First(); ok; ok = it.Next() {
+		s += string(it.Key())
+	}
+	if s != "01020304050607080910111213141516171819202122" {
+		t.Error("not asc", s)
+	}
+	s = ""
+	for ok := it.Last(); ok; ok = it.Prev() {
+		s += string(it.Key())
+	}
+	if s != "22212019181716151413121110090807060504030201" {
+		t.Error("not desc", s)
+	}
+	if !it.Seek("105") || string(it.Key()) != "11" {
+		t.Error("seek must stop at 11", string(it.Key()))
+	}
+	var v int
+	it.Value(&v)
+	if v != 11 {
+		t.Error("value must be 11", v)
+	}
+	// delete current key while iterating
+	db.Delete("11")
+	db.Delete("12")
+	if !it.Next() || string(it.Key()) != "13" {
+		t.Error("next must be 13", string(it.Key()))
+	}
+	it.Close()
+	if it.First() {
+		t.Error("closed iterator must not move")
+	}
+
+	it = db.NewIterator(&IteratorOptions{Prefix: []byte("1")})
+	s = ""
+	for ok := it.Last(); ok; ok = it.Prev() {
+		s += string(it.Key())
+	}
+	if s != "1918171615141310" {
+		t.Error("not prefix desc", s)
+	}
+	if it.Seek("2") {
+		t.Error("seek out of prefix must fail")
+	}
+	it.Close()
+
+	it = db.NewIterator(&IteratorOptions{Start: []byte("05"), End: []byte("08")})
+	s = ""
+	for ok := it.Seek("00"); ok; ok = it.Next() {
+		s += string(it.Key())
+	}
+	if s != "050607" {
+		t.Error("not range", s)
+	}
+	s = ""
+	for ok := it.Last(); ok; ok = it.Prev() {
+		s += string(it.Key())
+	}
+	if s != "070605" {
+		t.Error("not range desc", s)
+	}
+	it.Close()
+
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIteratorLarge(t *testing.T) {
+	f := "test/iterator_large"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	const n = 100000
+	b := new(Batch)
+	for i := n - 1; i >= 0; i-- {
+		b.Put(fmt.Sprintf("%06d", i), i)
+	}
+	if err = db.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	// every step must not sort or check order of all keys
+	start := time.Now()
+	i := 0
+	for k := range db.All() {
+		if string(k) != fmt.Sprintf("%06d", i) {
+			t.Fatal("not ordered", string(k), i)
+		}
+		i++
+	}
+	if i != n {
+		t.Error("must iterate all keys", i)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Error("iteration is too slow", d)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	f := "test/seq"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		db.Set(fmt.Sprintf("user:%d", i), i)
+		db.Set(fmt.Sprintf("item:%d", i), i)
+	}
+
+	s := ""
+	sum := 0
+	for k, v := range db.Prefix("user:") {
+		var i int
+		ValFromBinary(v, &i)
+		s += string(k) + " "
+		sum += i
+	}
+	if s != "user:1 user:2 user:3 user:4 user:5 " || sum != 15 {
+		t.Error("not prefix", s, sum)
+	}
+	n := 0
+	for range db.All() {
+		n++
+		if n == 7 {
+			break
+		}
+	}
+	if n != 7 {
+		t.Error("break must stop at 7", n)
+	}
+	s = ""
+	for k := range db.Range("item:2", "item:4") {
+		s += string(k) + " "
+	}
+	if s != "item:2 item:3 " {
+		t.Error("not range", s)
+	}
+
+	// read error stop iteration
+	os.Truncate(f, 0)
+	it := db.NewIterator(nil)
+	n = 0
+	for range it.All() {
+		n++
+	}
+	if n != 0 || it.Err() != io.EOF {
+		t.Error("read error must stop iteration", n, it.Err())
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,92 @@
+package fudge
+
+import (
+	"bytes"
+)
+
+// ListOptions for List
+type ListOptions struct {
+	Marker []byte // list after marker, use ListResult.Next for next page
+	Limit  int    // max count of keys and prefixes, 0 - all
+}
+
+// ListResult is one page of List
+type ListResult struct {
+	Keys      [][]byte // keys without delimiter after prefix
+	Prefixes  [][]byte // common prefixes up to first delimiter after prefix
+	Truncated bool     // true if there are more keys
+	Next      []byte   // marker of next page if truncated
+}
+
+// List return immediate children of prefix, like S3 ListObjects:
+// keys without delimiter after prefix and common prefixes of other keys.
+// Keys under common prefix are skipped with binary search.
+// Keys and prefixes are returned in one ascending order, empty delimiter list all keys.
+//
+//	res, _ := db.List([]byte("photos/"), []byte("/"), &fudge.ListOptions{Limit: 100})
+//	for res.Truncated {
+//		res, _ = db.List([]byte("photos/"), []byte("/"), &fudge.ListOptions{Limit: 100, Marker: res.Next})
+//	}
+func (db *DB) List(prefix, delimiter []byte, opts *ListOptions) (*ListResult, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	db.RLock()
+	defer db.RUnlock()
+	db.sort()
+	res := &ListResult{Keys: make([][]byte, 0), Prefixes: make([][]byte, 0)}
+	i := db.prefixFirst(prefix)
+	if m := opts.Marker; m != nil {
+		if cp := commonPrefix(m, prefix, delimiter); cp != nil && len(cp) == len(m) {
+			i = max(i, db.skipPrefix(db.seekGE(m), m))
+		} else {
+			i = max(i, db.seekGT(m))
+		}
+	}
+	for i < len(db.keys) && bytes.HasPrefix(db.keys[i], prefix) {
+		if opts.Limit > 0 && len(res.Keys)+len(res.Prefixes) == opts.Limit {
+			res.Truncated = true
+			break
+		}
+		k := db.keys[i]
+		if cp := commonPrefix(k, prefix, delimiter); cp != nil {
+			res.Prefixes = append(res.Prefixes, cp)
+			res.Next = cp
+			i = db.skipPrefix(i, cp)
+			continue
+		}
+		res.Keys = append(res.Keys, k)
+		res.Next = k
+		i++
+	}
+	if !res.Truncated {
+		res.Next = nil
+	}
+	return res, nil
+}
+
+// commonPrefix return k up to first delimiter after prefix or nil
+func commonPrefix(k, prefix, delimiter []byte) []byte {
+	if len(delimiter) == 0 || !bytes.HasPrefix(k, prefix) {
+		return nil
+	}
+	j := bytes.Index(k[len(prefix):], delimiter)
+	if j < 0 {
+		return nil
+	}
+	return k[:len(prefix)+j+len(delimiter)]
+}
+
+// skipPrefix return index of first key after keys with prefix starting from i
+func (db *DB) skipPrefix(i int, prefix []byte) int {
+	if _, ok := db.cmp.(bytewise); ok {
+		if end := prefixEnd(prefix); end != nil {
+			return max(i, db.seekGE(end))
+		}
+		return len(db.keys)
+	}
+	for i < len(db.keys) && bytes.HasPrefix(db.keys[i], prefix) {
+		i++
+	}
+	return i
+}
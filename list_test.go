@@ -0,0 +1,66 @@
+package fudge
+
+import (
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	f := "test/list"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a/1", "a/b/1", "a/b/2", "a/c/d/1", "a/2", "a/e/", "b/1", "a"} {
+		db.Set(k, 1)
+	}
+	res, err := db.List([]byte("a/"), []byte("/"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Keys) != 2 || string(res.Keys[0]) != "a/1" || string(res.Keys[1]) != "a/2" {
+		t.Error("bad keys", res.Keys)
+	}
+	if len(res.Prefixes) != 3 || string(res.Prefixes[0]) != "a/b/" || string(res.Prefixes[2]) != "a/e/" {
+		t.Error("bad prefixes", res.Prefixes)
+	}
+	if res.Truncated || res.Next != nil {
+		t.Error("must not be truncated", res)
+	}
+
+	// pages: a/1 a/2 | a/b/ a/c/ | a/e/
+	all := make([]string, 0)
+	opts := &ListOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		res, _ = db.List([]byte("a/"), []byte("/"), opts)
+		for _, k := range res.Keys {
+			all = append(all, string(k))
+		}
+		for _, p := range res.Prefixes {
+			all = append(all, string(p))
+		}
+		if !res.Truncated {
+			break
+		}
+		opts.Marker = res.Next
+	}
+	if len(all) != 5 || all[2] != "a/b/" || all[4] != "a/e/" {
+		t.Error("bad pages", all)
+	}
+
+	res, _ = db.List(nil, []byte("/"), nil)
+	if len(res.Keys) != 1 || len(res.Prefixes) != 2 {
+		t.Error("bad root", res.Keys, res.Prefixes)
+	}
+	res, _ = db.List([]byte("a/b/"), nil, nil)
+	if len(res.Keys) != 2 || len(res.Prefixes) != 0 {
+		t.Error("bad list without delimiter", res.Keys)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,38 @@
+package fudge
+
+import "errors"
+
+// ErrNoMerge - Config.Merge not set
+var ErrNoMerge = errors.New("error: merge function not set")
+
+// MergeFunc return new value from existing value and operand.
+// existing is nil if key not exists.
+// All values are in binary form, as stored in db.
+type MergeFunc func(key, existing, operand []byte) ([]byte, error)
+
+// Merge apply Config.Merge to current value of key and operand
+// and store result. Merge runs under db write lock,
+// so concurrent merges of same key never lose updates.
+func (db *DB) Merge(key any, operand any) error {
+	if db.merge == nil {
+		return ErrNoMerge
+	}
+	k, op, err := db.keyVal(key, operand)
+	if err != nil {
+		return err
+	}
+	db.Lock()
+	defer db.Unlock()
+	var existing []byte
+	if val, ok := db.live(k); ok {
+		existing, err = db.readVal(val)
+		if err != nil {
+			return err
+		}
+	}
+	v, err := db.merge(k, existing, op)
+	if err != nil {
+		return err
+	}
+	return db.set(k, v)
+}
@@ -0,0 +1,68 @@
+package fudge
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestMerge(t *testing.T) {
+	// append operand to list
+	appendMerge := func(key, existing, operand []byte) ([]byte, error) {
+		var list []string
+		if existing != nil {
+			if err := cbor.Unmarshal(existing, &list); err != nil {
+				return nil, err
+			}
+		}
+		var s string
+		if err := cbor.Unmarshal(operand, &s); err != nil {
+			return nil, err
+		}
+		return ValToBinary(append(list, s))
+	}
+	f := "test/merge"
+	db, err := Open(f, &Config{Merge: appendMerge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for _, s := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.Merge("list", s); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	var list []string
+	db.Get("list", &list)
+	slices.Sort(list)
+	if !slices.Equal(list, []string{"a", "b", "c", "d"}) {
+		t.Error("list must be abcd", list)
+	}
+	db.Set("str", 1)
+	if err = db.Merge("str", "e"); err == nil {
+		t.Error("merge to int must fail")
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Merge("list", "a"); err != ErrNoMerge {
+		t.Error("must be ErrNoMerge", err)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,298 @@
+package fudge
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query select keys by key bounds and filter over decoded values.
+// Nil fields are not used.
+type Query struct {
+	Prefix []byte // only keys with prefix
+	Start  any    // first key, included
+	End    any    // last key, not included
+	Filter Filter // only values matched by filter
+	Limit  int    // 0 - all keys
+	Offset int    // skip offset matched keys
+	Desc   bool   // descending order
+}
+
+// Filter match decoded value.
+// Value is decoded by db codec to any: structs are maps with field names as keys.
+type Filter func(v any) bool
+
+// Eq match if field at path equal value.
+// Path is dot separated field names or array indexes, "" is value itself:
+//
+//	fudge.Eq("Customer.Email", "a@b.c")
+func Eq(path string, value any) Filter {
+	return field(path, func(v any) bool {
+		c, ok := compareAny(v, value)
+		if ok {
+			return c == 0
+		}
+		return reflect.DeepEqual(v, value)
+	})
+}
+
+// Ne match if field at path exists and not equal value
+func Ne(path string, value any) Filter {
+	return And(Exists(path), Not(Eq(path, value)))
+}
+
+// Lt match if field at path less than value
+func Lt(path string, value any) Filter {
+	return cmpFilter(path, value, func(c int) bool { return c < 0 })
+}
+
+// Le match if field at path less or equal value
+func Le(path string, value any) Filter {
+	return cmpFilter(path, value, func(c int) bool { return c <= 0 })
+}
+
+// Gt match if field at path greater than value
+func Gt(path string, value any) Filter {
+	return cmpFilter(path, value, func(c int) bool { return c > 0 })
+}
+
+// Ge match if field at path greater or equal value
+func Ge(path string, value any) Filter {
+	return cmpFilter(path, value, func(c int) bool { return c >= 0 })
+}
+
+// In match if field at path equal any of values
+func In(path string, values ...any) Filter {
+	filters := make([]Filter, len(values))
+	for i, v := range values {
+		filters[i] = Eq(path, v)
+	}
+	return Or(filters...)
+}
+
+// Exists match if value has field at path
+func Exists(path string) Filter {
+	return field(path, func(any) bool { return true })
+}
+
+// And match if all filters match
+func And(filters ...Filter) Filter {
+	return func(v any) bool {
+		for _, f := range filters {
+			if !f(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or match if any filter match
+func Or(filters ...Filter) Filter {
+	return func(v any) bool {
+		for _, f := range filters {
+			if f(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not match if filter not match
+func Not(filter Filter) Filter {
+	return func(v any) bool {
+		return !filter(v)
+	}
+}
+
+// Query return keys selected by q
+// Default query (if nil) return all keys in ascending order.
+func (db *DB) Query(q *Query) ([][]byte, error) {
+	if q == nil {
+		q = &Query{}
+	}
+	var s, e []byte
+	var err error
+	if q.Start != nil {
+		if s, err = db.keyToBinary(q.Start); err != nil {
+			return nil, err
+		}
+	}
+	if q.End != nil {
+		if e, err = db.keyToBinary(q.End); err != nil {
+			return nil, err
+		}
+	}
+	db.RLock()
+	defer db.RUnlock()
+	db.sort()
+	lo, hi := 0, len(db.keys)
+	if s != nil {
+		lo = db.seekGE(s)
+	}
+	if e != nil {
+		hi = db.seekGE(e)
+	}
+	if q.Prefix != nil {
+		lo = max(lo, db.prefixFirst(q.Prefix))
+		hi = min(hi, db.prefixLast(q.Prefix)+1)
+	}
+	arr := make([][]byte, 0)
+	offset := q.Offset
+	match := func(k []byte) (bool, error) {
+		if q.Filter == nil {
+			return true, nil
+		}
+		cmd := db.vals[string(k)]
+		b, err := db.readVal(cmd)
+		if err != nil {
+			return false, err
+		}
+		var v any
+		if db.unmarshal(cmd.codec, b, &v) != nil {
+			// not decodable values never match
+			return false, nil
+		}
+		return q.Filter(v), nil
+	}
+	for n := 0; n < hi-lo && (q.Limit == 0 || len(arr) < q.Limit); n++ {
+		i := lo + n
+		if q.Desc {
+			i = hi - 1 - n
+		}
+		ok, err := match(db.keys[i])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		arr = append(arr, db.keys[i])
+	}
+	return arr, nil
+}
+
+// field return filter which call match with field at path
+func field(path string, match func(v any) bool) Filter {
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, ".")
+	}
+	return func(v any) bool {
+		for _, p := range parts {
+			var ok bool
+			if v, ok = child(v, p); !ok {
+				return false
+			}
+		}
+		return match(v)
+	}
+}
+
+// child return field of map or element of array
+func child(v any, name string) (any, bool) {
+	switch v := v.(type) {
+	case map[any]any:
+		c, ok := v[name]
+		return c, ok
+	case map[string]any:
+		c, ok := v[name]
+		return c, ok
+	case []any:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil, false
+		}
+		return v[i], true
+	}
+	return nil, false
+}
+
+func cmpFilter(path string, value any, ok func(c int) bool) Filter {
+	return field(path, func(v any) bool {
+		c, comparable := compareAny(v, value)
+		return comparable && ok(c)
+	})
+}
+
+// compareAny compare numbers, strings, bytes and times.
+// Return false if values are not comparable.
+func compareAny(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case []byte:
+		if b, ok := b.([]byte); ok {
+			return bytes.Compare(a, b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, true
+			}
+			if !a {
+				return -1, true
+			}
+			return 1, true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+	}
+	x, ok := number(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := number(b)
+	if !ok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// number convert integer or float to big.Float, integers are exact
+func number(v any) (*big.Float, bool) {
+	f := new(big.Float)
+	switch v := v.(type) {
+	case int:
+		return f.SetInt64(int64(v)), true
+	case int8:
+		return f.SetInt64(int64(v)), true
+	case int16:
+		return f.SetInt64(int64(v)), true
+	case int32:
+		return f.SetInt64(int64(v)), true
+	case int64:
+		return f.SetInt64(v), true
+	case uint:
+		return f.SetUint64(uint64(v)), true
+	case uint8:
+		return f.SetUint64(uint64(v)), true
+	case uint16:
+		return f.SetUint64(uint64(v)), true
+	case uint32:
+		return f.SetUint64(uint64(v)), true
+	case uint64:
+		return f.SetUint64(v), true
+	case float32:
+		return number(float64(v))
+	case float64:
+		if math.IsNaN(v) {
+			return nil, false
+		}
+		return f.SetFloat64(v), true
+	}
+	return nil, false
+}
@@ -0,0 +1,61 @@
+package fudge
+
+import (
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	type customer struct {
+		Email string
+	}
+	type order struct {
+		Status   string
+		Total    float64
+		Items    int
+		Customer *customer
+	}
+	f := "test/query"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set("tenant:7:1", order{"failed", 10.5, 1, &customer{"a@b.c"}})
+	db.Set("tenant:7:2", order{"ok", 20, 2, nil})
+	db.Set("tenant:7:3", order{"failed", 30, 3, nil})
+	db.Set("tenant:7:4", order{"pending", 40, 4, nil})
+	db.Set("tenant:8:1", order{"failed", 50, 5, nil})
+	db.Set("raw", []byte{0xff})
+
+	keys, err := db.Query(&Query{Prefix: []byte("tenant:7:"), Filter: Eq("Status", "failed")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || string(keys[0]) != "tenant:7:1" {
+		t.Error("bad failed orders", keys)
+	}
+	keys, _ = db.Query(&Query{Filter: And(Ge("Total", 20), Lt("Items", 5)), Desc: true, Limit: 2, Offset: 1})
+	if len(keys) != 2 || string(keys[0]) != "tenant:7:3" || string(keys[1]) != "tenant:7:2" {
+		t.Error("bad total orders", keys)
+	}
+	keys, _ = db.Query(&Query{Filter: In("Status", "ok", "pending")})
+	if len(keys) != 2 {
+		t.Error("bad in", keys)
+	}
+	keys, _ = db.Query(&Query{Filter: Eq("Customer.Email", "a@b.c")})
+	if len(keys) != 1 {
+		t.Error("bad path", keys)
+	}
+	keys, _ = db.Query(&Query{Start: "tenant:7:2", End: "tenant:8", Filter: Not(Exists("Missing"))})
+	if len(keys) != 3 {
+		t.Error("bad range", keys)
+	}
+	keys, _ = db.Query(nil)
+	if len(keys) != 6 {
+		t.Error("nil query must return all keys", keys)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
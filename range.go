@@ -0,0 +1,61 @@
+package fudge
+
+// RangeOptions for KeysRange.
+// By default start included and end not included: [start, end)
+type RangeOptions struct {
+	ExcludeStart bool // don't include start key
+	IncludeEnd   bool // include end key
+	Limit        int  // 0 - all keys
+	Offset       int  // skip offset keys
+	Desc         bool // descending order
+}
+
+// KeysRange return keys between start and end.
+// Bounds don't need to exist in db, nil bound means no bound.
+// Default options (if nil): [start, end) in ascending order, all keys.
+func (db *DB) KeysRange(start, end any, opts *RangeOptions) ([][]byte, error) {
+	if opts == nil {
+		opts = &RangeOptions{}
+	}
+	var s, e []byte
+	var err error
+	if start != nil {
+		s, err = db.keyToBinary(start)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if end != nil {
+		e, err = db.keyToBinary(end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	db.RLock()
+	defer db.RUnlock()
+	db.sort()
+	lo, hi := 0, len(db.keys)
+	if s != nil {
+		lo = db.seekGE(s)
+		if opts.ExcludeStart {
+			lo = db.seekGT(s)
+		}
+	}
+	if e != nil {
+		hi = db.seekGE(e)
+		if opts.IncludeEnd {
+			hi = db.seekGT(e)
+		}
+	}
+	arr := make([][]byte, 0)
+	if opts.Desc {
+		for i := hi - 1 - opts.Offset; i >= lo && (opts.Limit == 0 || len(arr) < opts.Limit); i-- {
+			arr = append(arr, db.keys[i])
+		}
+		return arr, nil
+	}
+	for i := lo + opts.Offset; i < hi && (opts.Limit == 0 || len(arr) < opts.Limit); i++ {
+		arr = append(arr, db.keys[i])
+	}
+	return arr, nil
+}
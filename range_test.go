@@ -0,0 +1,55 @@
+package fudge
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeysRange(t *testing.T) {
+	f := "test/range"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := 1; m <= 3; m++ {
+		for d := 1; d <= 28; d += 9 {
+			db.Set(fmt.Sprintf("event:2024-%02d-%02d", m, d), d)
+		}
+	}
+	join := func(keys [][]byte) (s string) {
+		for _, k := range keys {
+			s += string(k)[11:] + " "
+		}
+		return s
+	}
+
+	// bounds not in db
+	keys, _ := db.KeysRange("event:2024-01-01", "event:2024-02-01", nil)
+	if join(keys) != "01-01 01-10 01-19 01-28 " {
+		t.Error("not january", join(keys))
+	}
+	keys, _ = db.KeysRange("event:2024-01-05", "event:2024-02-10", &RangeOptions{Desc: true, Limit: 3})
+	if join(keys) != "02-01 01-28 01-19 " {
+		t.Error("not desc limit", join(keys))
+	}
+	keys, _ = db.KeysRange("event:2024-02-01", "event:2024-02-19", &RangeOptions{ExcludeStart: true, IncludeEnd: true})
+	if join(keys) != "02-10 02-19 " {
+		t.Error("not (start, end]", join(keys))
+	}
+	keys, _ = db.KeysRange("event:2024-03", nil, &RangeOptions{Offset: 1})
+	if join(keys) != "03-10 03-19 03-28 " {
+		t.Error("not open end", join(keys))
+	}
+	keys, _ = db.KeysRange(nil, "event:2024-01-10", &RangeOptions{Desc: true, IncludeEnd: true, Offset: 1})
+	if join(keys) != "01-01 " {
+		t.Error("not open start", join(keys))
+	}
+	keys, _ = db.KeysRange("event:2025", "event:2026", nil)
+	if len(keys) != 0 {
+		t.Error("must be empty", join(keys))
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,421 @@
+package fudge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReadOnly - write to db opened with ReadOnly
+	ErrReadOnly = errors.New("error: db is read only")
+	// ErrNotReadOnly - follower db must be opened with ReadOnly
+	ErrNotReadOnly = errors.New("error: follower db is not read only")
+)
+
+// messages of replication stream, never stored in files
+const (
+	replSnapshot    uint8 = 0x40 + iota // start of snapshot, rev - revision of snapshot
+	replSnapshotEnd                     // end of snapshot, rev - revision of snapshot
+	replHeartbeat                       // rev - last revision of leader
+)
+
+var (
+	// ReplicationHeartbeat is interval of heartbeats from leader to idle follower,
+	// follower reconnect if leader is silent for 3 intervals
+	ReplicationHeartbeat = time.Second
+	// ReplicationRetry is pause of follower between reconnects
+	ReplicationRetry = time.Second
+)
+
+// ServeReplication accept followers on l and stream changes of db to them.
+// Db must be opened with ChangeLog. Follower get snapshot of db
+// if it is new or its changes are trimmed from change log.
+// Return error of l.Accept, close l to stop.
+func (db *DB) ServeReplication(l net.Listener) error {
+	db = db.root()
+	db.RLock()
+	fl := db.fl
+	db.RUnlock()
+	if fl == nil {
+		return ErrNoChangeLog
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go db.serveFollower(conn)
+	}
+}
+
+// serveFollower send snapshot and changes to follower until error
+func (db *DB) serveFollower(conn net.Conn) error {
+	defer conn.Close()
+	var seq uint64
+	if err := binary.Read(conn, binary.BigEndian, &seq); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(conn)
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	var off int64
+	gen := -1
+	resync := seq == 0
+	for {
+		if resync {
+			var err error
+			if seq, err = db.sendSnapshot(w); err != nil {
+				return err
+			}
+			resync = false
+		}
+		wait := db.logWait()
+		db.RLock()
+		st, err := db.fl.Stat()
+		logGen, rev := db.logGen, db.rev
+		db.RUnlock()
+		if err != nil {
+			return err
+		}
+		if logGen != gen {
+			// change log is trimmed, read new file from start
+			if f != nil {
+				f.Close()
+			}
+			if f, err = os.Open(db.name + ".log"); err != nil {
+				return err
+			}
+			gen, off = logGen, 0
+		}
+		var werr error
+		off, err = readLog(f, off, st.Size(), func(t uint8, key []byte, cmd *Cmd, val []byte) bool {
+			if t == recBegin {
+				resync = seq < cmd.Rev
+				return !resync
+			}
+			if cmd.Rev <= seq {
+				return true
+			}
+			seq = cmd.Rev
+			werr = writeRecord(w, t, key, val, cmd)
+			return werr == nil
+		})
+		if err == nil {
+			err = werr
+		}
+		if err != nil {
+			return err
+		}
+		if resync {
+			continue
+		}
+		if err = writeRecord(w, replHeartbeat, nil, nil, &Cmd{Rev: rev}); err != nil {
+			return err
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+		select {
+		case <-wait:
+		case <-time.After(ReplicationHeartbeat):
+		}
+	}
+}
+
+// sendSnapshot write all buckets and keys of db to w and return revision of snapshot
+func (db *DB) sendSnapshot(w io.Writer) (uint64, error) {
+	db.Lock()
+	rev := db.rev
+	views := map[string]*Snapshot{"": db.snapshot()}
+	for name, b := range db.buckets {
+		views[name] = b.snapshot()
+	}
+	db.Unlock()
+	defer func() {
+		for _, s := range views {
+			s.Release()
+		}
+	}()
+	err := writeRecord(w, replSnapshot, nil, nil, &Cmd{Rev: rev})
+	for name, s := range views {
+		v := s.view
+		if name != "" && err == nil {
+			err = writeRecord(w, recBucket, []byte(name), nil, &Cmd{})
+		}
+		for _, k := range v.keys {
+			if err != nil {
+				return 0, err
+			}
+			cmd := v.vals[string(k)]
+			var val []byte
+			if val, err = v.readVal(cmd); err != nil {
+				return 0, err
+			}
+			t, rk := recSet, k
+			if name != "" {
+				t, rk = recSet|recInBucket, append([]byte{byte(len(name))}, append([]byte(name), k...)...)
+			}
+			err = writeRecord(w, t, rk, val, cmd)
+		}
+	}
+	if err == nil {
+		err = writeRecord(w, replSnapshotEnd, nil, nil, &Cmd{Rev: rev})
+	}
+	return rev, err
+}
+
+// writeRecord write record with value and metadata of cmd to w
+func writeRecord(w io.Writer, t uint8, key, val []byte, cmd *Cmd) error {
+	buf := new(bytes.Buffer)
+	encodeKey(buf, t, 0, uint32(len(val)), cmd.Rev, cmd.codec, cmd.flags, cmd.expire, key)
+	buf.Write(val)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// FollowerStats is state of replication on follower
+type FollowerStats struct {
+	Connected   bool      // connected to leader
+	Applied     uint64    // last applied revision of leader
+	Leader      uint64    // last known revision of leader
+	Lag         uint64    // number of changes not applied yet
+	LastContact time.Time // time of last message from leader
+	Reconnects  int       // number of reconnects to leader
+	Err         error     // last connection error
+}
+
+// Follower apply changes of leader to db
+type Follower struct {
+	db    *DB
+	addr  string
+	mu    sync.Mutex
+	stats FollowerStats
+	done  chan struct{}
+}
+
+// Follow connect to leader at addr and apply its changes to db until ctx is done.
+// Db must be opened with ReadOnly, it may be read while following.
+// Follower reconnect on errors and resume from last applied revision.
+//
+//	f, err := replica.Follow(ctx, "leader:7000")
+//	fmt.Println(f.Stats().Lag)
+func (db *DB) Follow(ctx context.Context, addr string) (*Follower, error) {
+	db = db.root()
+	if !db.readonly {
+		return nil, ErrNotReadOnly
+	}
+	f := &Follower{db: db, addr: addr, done: make(chan struct{})}
+	f.stats.Applied = db.Revision()
+	go f.run(ctx)
+	return f, nil
+}
+
+// Stats return replication state
+func (f *Follower) Stats() FollowerStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// Done return channel closed when follower is stopped
+func (f *Follower) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *Follower) run(ctx context.Context) {
+	defer close(f.done)
+	for {
+		err := f.follow(ctx)
+		f.mu.Lock()
+		f.stats.Connected = false
+		f.stats.Err = err
+		f.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ReplicationRetry):
+		}
+		f.mu.Lock()
+		f.stats.Reconnects++
+		f.mu.Unlock()
+	}
+}
+
+// follow apply stream of one connection to leader until error
+func (f *Follower) follow(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", f.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	db := f.db
+	if err = binary.Write(conn, binary.BigEndian, db.Revision()); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.stats.Connected = true
+	f.stats.Err = nil
+	f.mu.Unlock()
+	r := bufio.NewReaderSize(conn, headerSize(recVersion)+math.MaxUint16)
+	// keys of snapshot by bucket name, nil if snapshot is not in progress
+	var seen map[string]map[string]struct{}
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(3 * ReplicationHeartbeat))
+		t, key, cmd, val, _, err := readRecord(r)
+		if err != nil {
+			return err
+		}
+		leader := cmd.Rev
+		switch t {
+		case replHeartbeat:
+		case replSnapshot:
+			seen = map[string]map[string]struct{}{"": {}}
+			err = db.beginSync()
+		case replSnapshotEnd:
+			err = db.endSync(seen, cmd.Rev)
+			seen = nil
+		default:
+			err = db.applyReplica(t, key, val, cmd, seen)
+		}
+		if err != nil {
+			return err
+		}
+		applied := db.Revision()
+		f.mu.Lock()
+		if seen == nil {
+			f.stats.Applied = applied
+		}
+		f.stats.Leader = max(f.stats.Leader, leader, f.stats.Applied)
+		f.stats.Lag = f.stats.Leader - f.stats.Applied
+		f.stats.LastContact = time.Now()
+		f.mu.Unlock()
+	}
+}
+
+// beginSync mark start of snapshot from leader
+func (db *DB) beginSync() error {
+	db.Lock()
+	defer db.Unlock()
+	_, err := writeKey(db.fk, recSyncBegin, 0, 0, 0, 0, 0, 0, nil, -1)
+	if err != nil && db.storemode != 2 {
+		return err
+	}
+	db.rev = 0
+	return nil
+}
+
+// endSync delete keys and buckets missed in snapshot from leader
+// and set revision of snapshot
+func (db *DB) endSync(seen map[string]map[string]struct{}, rev uint64) error {
+	db.Lock()
+	defer db.Unlock()
+	db.replicating = true
+	defer func() { db.replicating = false }()
+	for name := range db.buckets {
+		if _, ok := seen[name]; !ok {
+			db.rev = rev - 1
+			if err := db.deleteBucket(name); err != nil {
+				return err
+			}
+		}
+	}
+	for name, keys := range seen {
+		b := db
+		if name != "" {
+			b = db.buckets[name]
+		}
+		for _, k := range slices.Clone(b.keys) {
+			if _, ok := keys[string(k)]; ok {
+				continue
+			}
+			db.rev = rev - 1
+			if err := b.del(k); err != nil {
+				return err
+			}
+		}
+	}
+	db.rev = rev
+	_, err := writeKey(db.fk, recSyncEnd, 0, 0, rev, 0, 0, 0, nil, -1)
+	if err != nil && db.storemode != 2 {
+		return err
+	}
+	return nil
+}
+
+// applyReplica apply record from leader,
+// seen collect keys if snapshot is in progress
+func (db *DB) applyReplica(t uint8, key, val []byte, cmd *Cmd, seen map[string]map[string]struct{}) error {
+	db.Lock()
+	defer db.Unlock()
+	db.replicating = true
+	defer func() { db.replicating = false }()
+	if seen == nil {
+		if cmd.Rev <= db.rev {
+			// already applied
+			return nil
+		}
+		db.rev = cmd.Rev - 1
+		// change may be a no-op, like delete of missed key
+		defer func() { db.rev = max(db.rev, cmd.Rev) }()
+	} else if cmd.Rev > 0 {
+		// snapshot keep revisions of keys
+		db.rev = cmd.Rev - 1
+	}
+	switch t {
+	case recBucket:
+		if seen != nil && seen[string(key)] == nil {
+			seen[string(key)] = map[string]struct{}{}
+		}
+		_, err := db.createBucket(string(key))
+		return err
+	case recDropBucket:
+		if err := db.deleteBucket(string(key)); err != ErrKeyNotFound {
+			return err
+		}
+		return nil
+	}
+	target, name := db, ""
+	if t&recInBucket != 0 {
+		if len(key) == 0 || len(key) < 1+int(key[0]) {
+			return ErrFormat
+		}
+		name = string(key[1 : 1+key[0]])
+		key = key[1+key[0]:]
+		var err error
+		if target, err = db.createBucket(name); err != nil {
+			return err
+		}
+	}
+	switch t &^ recInBucket {
+	case recSet:
+		if seen != nil {
+			if seen[name] == nil {
+				seen[name] = map[string]struct{}{}
+			}
+			seen[name][string(key)] = struct{}{}
+		}
+		return target.put(key, val, cmd.codec, cmd.flags, cmd.expire)
+	case recDelete:
+		if err := target.del(key); err != ErrKeyNotFound {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package fudge
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor poll cond until it is true or timeout
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout")
+}
+
+func TestReplication(t *testing.T) {
+	if ReplicationHeartbeat != 50*time.Millisecond {
+		// set once, goroutines of leader may outlive test
+		ReplicationHeartbeat = 50 * time.Millisecond
+		ReplicationRetry = 50 * time.Millisecond
+	}
+	fl, ff := "test/leader", "test/follower"
+	DeleteFile(fl)
+	DeleteFile(ff)
+	leader, err := Open(fl, &Config{ChangeLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leader.DeleteFile()
+	leader.Set("a", 1)
+	leader.Set("b", 2)
+	users, _ := leader.Bucket("users")
+	users.Set("alice", 30)
+	long := strings.Repeat("k", 5000)
+	leader.Set(long, 6)
+
+	follower, err := Open(ff, &Config{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = follower.Set("a", 1); err != ErrReadOnly {
+		t.Error("follower must be read only", err)
+	}
+	if _, err = leader.Follow(context.Background(), "localhost:0"); err != ErrNotReadOnly {
+		t.Error("leader can't follow", err)
+	}
+
+	// follower start before leader and reconnect
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err := follower.Follow(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if l, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	go leader.ServeReplication(l)
+
+	// snapshot
+	waitFor(t, func() bool { return f.Stats().Applied == leader.Revision() })
+	var v int
+	if err = follower.Get("b", &v); err != nil || v != 2 {
+		t.Error("bad snapshot value", v, err)
+	}
+	fusers, _ := follower.Bucket("users")
+	if err = fusers.Get("alice", &v); err != nil || v != 30 {
+		t.Error("bad snapshot bucket value", v, err)
+	}
+	if err = follower.Get(long, &v); err != nil || v != 6 {
+		t.Error("bad snapshot long key value", v, err)
+	}
+	st := f.Stats()
+	if !st.Connected || st.Lag != 0 || st.Reconnects == 0 {
+		t.Error("bad stats", st)
+	}
+
+	// changes
+	leader.Set("c", 3)
+	leader.Delete("a")
+	users.Set("bob", 40)
+	leader.Set(long+"2", 7)
+	waitFor(t, func() bool { return f.Stats().Applied == leader.Revision() })
+	if has, _ := follower.Has("a"); has {
+		t.Error("a must be deleted")
+	}
+	if err = follower.Get("c", &v); err != nil || v != 3 {
+		t.Error("bad value", v, err)
+	}
+	if err = fusers.Get("bob", &v); err != nil || v != 40 {
+		t.Error("bad bucket value", v, err)
+	}
+	if err = follower.Get(long+"2", &v); err != nil || v != 7 {
+		t.Error("bad long key value", v, err)
+	}
+	leader.Delete(long)
+	leader.Delete(long + "2")
+	leader.DeleteBucket("users")
+	waitFor(t, func() bool { return f.Stats().Applied == leader.Revision() })
+	if len(follower.ListBuckets()) != 0 {
+		t.Error("bucket must be deleted", follower.ListBuckets())
+	}
+	if follower.Revision() != leader.Revision() {
+		t.Error("revisions must be equal", follower.Revision(), leader.Revision())
+	}
+
+	// follower restart resume from last revision
+	cancel()
+	<-f.Done()
+	follower.Close()
+	leader.Set("d", 4)
+	leader.TrimChanges(leader.Revision())
+	leader.Set("e", 5)
+	if follower, err = Open(ff, &Config{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer follower.DeleteFile()
+	if follower.Revision() == 0 {
+		t.Error("follower must keep revision")
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	if f, err = follower.Follow(ctx, addr); err != nil {
+		t.Fatal(err)
+	}
+	// changes are trimmed, follower get new snapshot
+	waitFor(t, func() bool { return f.Stats().Applied == leader.Revision() })
+	keys, _ := follower.Keys(nil, 0, 0, true)
+	if len(keys) != 4 {
+		t.Error("must be 4 keys", keys)
+	}
+	l.Close()
+}
@@ -0,0 +1,79 @@
+package fudge
+
+import "iter"
+
+// All return iterator over all key/value pairs in ascending order.
+// Values are in binary form, keys deleted while iterating are skipped.
+// Read error stop iteration, use Iterator.All to check it with Err.
+//
+//	for k, v := range db.All() {
+//		...
+//	}
+func (db *DB) All() iter.Seq2[[]byte, []byte] {
+	return db.seq(nil)
+}
+
+// Prefix return iterator over key/value pairs with key prefix in ascending order
+func (db *DB) Prefix(prefix any) iter.Seq2[[]byte, []byte] {
+	p, err := db.keyToBinary(prefix)
+	if err != nil {
+		return func(yield func([]byte, []byte) bool) {}
+	}
+	return db.seq(&IteratorOptions{Prefix: p})
+}
+
+// Range return iterator over key/value pairs in [lo, hi) in ascending order.
+// nil bound means no bound.
+func (db *DB) Range(lo, hi any) iter.Seq2[[]byte, []byte] {
+	opts := &IteratorOptions{}
+	var err error
+	if lo != nil {
+		if opts.Start, err = db.keyToBinary(lo); err != nil {
+			return func(yield func([]byte, []byte) bool) {}
+		}
+	}
+	if hi != nil {
+		if opts.End, err = db.keyToBinary(hi); err != nil {
+			return func(yield func([]byte, []byte) bool) {}
+		}
+	}
+	return db.seq(opts)
+}
+
+// seq return iterator of db, read error stop iteration
+func (db *DB) seq(opts *IteratorOptions) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		db.NewIterator(opts).All()(yield)
+	}
+}
+
+// All return iterator over key/value pairs of it in ascending order and close it.
+// Keys deleted while iterating are skipped, other errors of value read
+// stop iteration and are returned by Err.
+//
+//	it := db.NewIterator(&fudge.IteratorOptions{Prefix: []byte("user:")})
+//	for k, v := range it.All() {
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (it *Iterator) All() iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		defer it.Close()
+		for ok := it.First(); ok; ok = it.Next() {
+			var v []byte
+			err := it.Value(&v)
+			if err == ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				it.err = err
+				return
+			}
+			if !yield(it.Key(), v) {
+				return
+			}
+		}
+	}
+}
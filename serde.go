@@ -3,11 +3,22 @@ package fudge
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"log"
+	"math"
 
 	"github.com/fxamacker/cbor/v2"
 )
 
+// ErrKeyEncoding - db was created with another key encoding
+var ErrKeyEncoding = errors.New("error: key encoding mismatch")
+
+// Key encodings for Config.KeyEncoding
+const (
+	KeyBinary  = iota // KeyToBinary, negative numbers sorted after positive
+	KeyOrdered        // OrderedKeyToBinary, numbers sorted by value
+)
+
 var enc cbor.EncMode
 
 var opt = cbor.CanonicalEncOptions()
@@ -29,6 +40,18 @@ func marshal(v any) ([]byte, error) {
 	return enc.Marshal(v)
 }
 
+// unmarshal decode stored value
+// *[]byte receive value as is
+func unmarshal(b []byte, value any) error {
+	switch value := value.(type) {
+	case *[]byte:
+		*value = b
+		return nil
+	default:
+		return cbor.Unmarshal(b, value)
+	}
+}
+
 // KeyToBinary return key in bytes
 func KeyToBinary(v any) ([]byte, error) {
 	var err error
@@ -54,12 +77,77 @@ func KeyToBinary(v any) ([]byte, error) {
 		return p, err
 	case string:
 		return []byte(v), nil
+	case Tuple:
+		return v.Pack()
 	default:
 		return marshal(v)
 	}
 }
 
-// ValToBinary return value in bytes
+// OrderedKeyToBinary return key in bytes, binary order of
+// signed integers and floats is the same as numeric order.
+// Signed integers stored big-endian with flipped sign bit,
+// floats stored with flipped sign bit if positive and with all bits flipped if negative.
+// Other keys are same as KeyToBinary.
+func OrderedKeyToBinary(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case int:
+		return binary.BigEndian.AppendUint64(nil, uint64(v)^(1<<63)), nil
+	case int8:
+		return []byte{uint8(v) ^ (1 << 7)}, nil
+	case int16:
+		return binary.BigEndian.AppendUint16(nil, uint16(v)^(1<<15)), nil
+	case int32:
+		return binary.BigEndian.AppendUint32(nil, uint32(v)^(1<<31)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(nil, uint64(v)^(1<<63)), nil
+	case float32:
+		return FloatKeyToOrdered(binary.BigEndian.AppendUint32(nil, math.Float32bits(v))), nil
+	case float64:
+		return FloatKeyToOrdered(binary.BigEndian.AppendUint64(nil, math.Float64bits(v))), nil
+	default:
+		return KeyToBinary(v)
+	}
+}
+
+// IntKeyToOrdered convert signed integer key from KeyToBinary to OrderedKeyToBinary
+// Use it with DB.Rekey for migration to KeyOrdered only if all keys of db are signed integers,
+// it flip first byte of any key, so string key "name" become "\xeeame".
+func IntKeyToOrdered(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	o := bytes.Clone(b)
+	o[0] ^= 0x80
+	return o
+}
+
+// FloatKeyToOrdered convert float key from KeyToBinary to OrderedKeyToBinary
+// Use it with DB.Rekey for migration to KeyOrdered only if all keys of db are floats.
+func FloatKeyToOrdered(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	o := bytes.Clone(b)
+	if o[0]&0x80 == 0 {
+		o[0] ^= 0x80
+		return o
+	}
+	for i := range o {
+		o[i] = ^o[i]
+	}
+	return o
+}
+
+// keyToBinary return key in bytes with db key encoding
+func (db *DB) keyToBinary(v any) ([]byte, error) {
+	if db.keyEncoding == KeyOrdered {
+		return OrderedKeyToBinary(v)
+	}
+	return KeyToBinary(v)
+}
+
+// ValToBinary return value in bytes with CBORCodec
 func ValToBinary(v any) ([]byte, error) {
 	switch v := v.(type) {
 	case []byte:
@@ -68,3 +156,30 @@ func ValToBinary(v any) ([]byte, error) {
 		return marshal(v)
 	}
 }
+
+// ValFromBinary decode value returned in binary form,
+// for example by All, Prefix or Range iterators.
+// Values are decoded with CBORCodec, use Codec.Unmarshal for db with other codec.
+func ValFromBinary(b []byte, v any) error {
+	return unmarshal(b, v)
+}
+
+// keyFromBinary decode key with db key encoding, v must be pointer
+func (db *DB) keyFromBinary(b []byte, v any) error {
+	if db.keyEncoding == KeyOrdered && len(b) > 0 {
+		switch v.(type) {
+		case *int, *int8, *int16, *int32, *int64:
+			b = IntKeyToOrdered(b)
+		case *float32, *float64:
+			b = bytes.Clone(b)
+			if b[0]&0x80 != 0 {
+				b[0] ^= 0x80
+			} else {
+				for i := range b {
+					b[i] = ^b[i]
+				}
+			}
+		}
+	}
+	return KeyFromBinary(b, v)
+}
@@ -0,0 +1,141 @@
+package fudge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestOrderedKeyToBinary(t *testing.T) {
+	ints := []any{math.MinInt64, -100, -1, 0, 1, 100, math.MaxInt64}
+	floats := []any{math.Inf(-1), -1e10, -1.5, -0.1, 0.0, 0.1, 1.5, 1e10, math.Inf(1)}
+	for _, vals := range [][]any{ints, floats} {
+		var prev []byte
+		for _, v := range vals {
+			b, err := OrderedKeyToBinary(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prev != nil && bytes.Compare(prev, b) >= 0 {
+				t.Error("not ordered", v)
+			}
+			prev = b
+		}
+	}
+	small := []any{int8(-5), int8(5), int16(-5), int16(5), int32(-5), int32(5), float32(-5), float32(5)}
+	for i := 0; i < len(small); i += 2 {
+		a, _ := OrderedKeyToBinary(small[i])
+		b, _ := OrderedKeyToBinary(small[i+1])
+		if bytes.Compare(a, b) >= 0 {
+			t.Errorf("%T not ordered", small[i])
+		}
+	}
+
+	// migration helpers
+	for _, v := range []any{-7, 7, -1.5, 2.5} {
+		old, _ := KeyToBinary(v)
+		want, _ := OrderedKeyToBinary(v)
+		var got []byte
+		if _, ok := v.(int); ok {
+			got = IntKeyToOrdered(old)
+		} else {
+			got = FloatKeyToOrdered(old)
+		}
+		if !bytes.Equal(got, want) {
+			t.Error("migration of", v, got, want)
+		}
+	}
+}
+
+func TestKeyOrdered(t *testing.T) {
+	f := "test/ordered"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := -3; i <= 3; i++ {
+		db.Set(i, i)
+	}
+	db.Close()
+
+	if _, err = Open(f, &Config{KeyEncoding: KeyOrdered}); err != ErrKeyEncoding {
+		t.Fatal("must be ErrKeyEncoding", err)
+	}
+
+	// migrate
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Rekey(KeyOrdered, IntKeyToOrdered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err = Open(f, &Config{}); err != ErrKeyEncoding {
+		t.Fatal("must be ErrKeyEncoding after rekey", err)
+	}
+
+	db, err = Open(f, &Config{KeyEncoding: KeyOrdered})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	db.Get(-2, &v)
+	if v != -2 {
+		t.Error("-2 must be -2", v)
+	}
+	keys, _ := db.KeysRange(-2, 2, nil)
+	res := make([]int, 0)
+	for _, k := range keys {
+		db.Get(k, &v)
+		res = append(res, v)
+	}
+	if !sort.IntsAreSorted(res) || len(res) != 4 || res[0] != -2 {
+		t.Error("not ordered", res)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyEncodingLegacy(t *testing.T) {
+	f := "test/encoding_legacy"
+	DeleteFile(f)
+	os.MkdirAll("test", 0755)
+	// value file and version 0 index record for int key 5 with value 1,
+	// written before key encoding was stored
+	k, _ := KeyToBinary(5)
+	os.WriteFile(f, []byte{1}, 0644)
+	rec := make([]byte, 16, 16+len(k))
+	binary.BigEndian.PutUint32(rec[6:10], 1) // size
+	binary.BigEndian.PutUint16(rec[14:16], uint16(len(k)))
+	os.WriteFile(f+".idx", append(rec, k...), 0644)
+
+	if _, err := Open(f, &Config{KeyEncoding: KeyOrdered}); err != ErrKeyEncoding {
+		t.Fatal("db without stored encoding must be KeyBinary", err)
+	}
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if err = db.Get(5, &v); err != nil || v != 1 {
+		t.Error("5 must be 1", v, err)
+	}
+	if err = db.Rekey(KeyOrdered, IntKeyToOrdered); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if db, err = Open(f, &Config{KeyEncoding: KeyOrdered}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteFile()
+	if err = db.Get(5, &v); err != nil || v != 1 {
+		t.Error("5 must be 1 after rekey", v, err)
+	}
+}
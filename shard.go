@@ -0,0 +1,273 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+)
+
+// ErrShardCount - sharded db was created with another number of shards
+var ErrShardCount = errors.New("error: shards count mismatch")
+
+const defaultShards = 4
+
+// ShardedDB spread keys over several DB by key hash.
+// Every shard has own lock and own files,
+// so writers on different shards don't block each other.
+type ShardedDB struct {
+	name   string
+	shards []*DB
+}
+
+// OpenSharded return sharded db object if it opened.
+// Shards stored in files f.0, f.1 ... f.N-1, N = cfg.Shards.
+// The number of shards must not change for existing db.
+// Default Config (if nil): &Config{FileMode: 0644, DirMode: 0755, SyncInterval: 0, Shards: 4}
+func OpenSharded(f string, cfg *Config) (*ShardedDB, error) {
+	if cfg == nil {
+		cfg = DefaultConfig
+	}
+	n := cfg.Shards
+	if n <= 0 {
+		n = defaultShards
+	}
+	if f != "" && fileExists(shardName(f, 0)) {
+		if fileExists(shardName(f, n)) || !fileExists(shardName(f, n-1)) {
+			return nil, ErrShardCount
+		}
+	}
+	sdb := &ShardedDB{name: f, shards: make([]*DB, n)}
+	for i := range sdb.shards {
+		var db *DB
+		var err error
+		if f == "" {
+			// pure inmemory shards can't be shared with the registry
+			db, err = newDB("", cfg)
+		} else {
+			db, err = Open(shardName(f, i), cfg)
+		}
+		if err != nil {
+			sdb.Close()
+			return nil, err
+		}
+		sdb.shards[i] = db
+	}
+	return sdb, nil
+}
+
+func shardName(f string, i int) string {
+	return fmt.Sprintf("%s.%d", f, i)
+}
+
+func fileExists(f string) bool {
+	_, err := os.Stat(f)
+	return err == nil
+}
+
+// shard return db for binary key
+func (sdb *ShardedDB) shard(k []byte) *DB {
+	h := fnv.New32a()
+	h.Write(k)
+	return sdb.shards[h.Sum32()%uint32(len(sdb.shards))]
+}
+
+// keyToBinary return key in bytes with encoding of shards
+func (sdb *ShardedDB) keyToBinary(v any) ([]byte, error) {
+	return sdb.shards[0].keyToBinary(v)
+}
+
+// Set store any key value to db
+func (sdb *ShardedDB) Set(key any, value any) error {
+	k, err := sdb.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	return sdb.shard(k).Set(k, value)
+}
+
+// Get return value by key
+// Return error if any.
+func (sdb *ShardedDB) Get(key any, value any) error {
+	k, err := sdb.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	return sdb.shard(k).Get(k, value)
+}
+
+// Has return true if key exists.
+// Return error if any.
+func (sdb *ShardedDB) Has(key any) (bool, error) {
+	k, err := sdb.keyToBinary(key)
+	if err != nil {
+		return false, err
+	}
+	return sdb.shard(k).Has(k)
+}
+
+// Delete remove key
+// Returns error if key not found
+func (sdb *ShardedDB) Delete(key any) error {
+	k, err := sdb.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	return sdb.shard(k).Delete(k)
+}
+
+// Count returns the number of items in all shards.
+func (sdb *ShardedDB) Count() (int, error) {
+	total := 0
+	for _, db := range sdb.shards {
+		cnt, err := db.Count()
+		if err != nil {
+			return -1, err
+		}
+		total += cnt
+	}
+	return total, nil
+}
+
+// FileSize returns the total size of the disk storage used by all shards.
+func (sdb *ShardedDB) FileSize() (int64, error) {
+	var total int64
+	for _, db := range sdb.shards {
+		size, err := db.FileSize()
+		if err != nil {
+			return -1, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// KeysByPrefix return keys with prefix from all shards
+// in ascending  or descending order (false - descending,true - ascending)
+// if limit == 0 return all keys
+// if offset > 0 - skip offset records
+func (sdb *ShardedDB) KeysByPrefix(prefix []byte, limit, offset int, asc bool) ([][]byte, error) {
+	n := 0
+	if limit > 0 {
+		n = limit + offset
+	}
+	found := false
+	lists := make([][][]byte, 0, len(sdb.shards))
+	for _, db := range sdb.shards {
+		keys, err := db.KeysByPrefix(prefix, n, 0, asc)
+		if err == ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		lists = append(lists, keys)
+	}
+	if !found {
+		return make([][]byte, 0), ErrKeyNotFound
+	}
+	return mergeKeys(sdb.shards[0].cmp, lists, limit, offset, asc), nil
+}
+
+// Keys return keys from all shards in ascending  or descending order (false - descending,true - ascending)
+// if limit == 0 return all keys
+// if offset > 0 - skip offset records
+// If from not nil - return keys after from (from not included)
+func (sdb *ShardedDB) Keys(from any, limit, offset int, asc bool) ([][]byte, error) {
+	var k []byte
+	if from != nil {
+		var err error
+		k, err = sdb.keyToBinary(from)
+		if err != nil {
+			return make([][]byte, 0), err
+		}
+		if len(k) > 1 && bytes.Equal(k[len(k)-1:], []byte("*")) {
+			switch from.(type) {
+			case []byte, string:
+				return sdb.KeysByPrefix(k[:len(k)-1], limit, offset, asc)
+			}
+		}
+		has, err := sdb.shard(k).Has(k)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			return nil, ErrKeyNotFound
+		}
+	}
+	n := 0
+	if limit > 0 {
+		n = limit + offset
+	}
+	lists := make([][][]byte, 0, len(sdb.shards))
+	for _, db := range sdb.shards {
+		lists = append(lists, db.keysAfter(k, n, asc))
+	}
+	return mergeKeys(sdb.shards[0].cmp, lists, limit, offset, asc), nil
+}
+
+// Close - sync & close all shards.
+// Return error if any.
+func (sdb *ShardedDB) Close() (err error) {
+	for _, db := range sdb.shards {
+		if db == nil {
+			continue
+		}
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// DeleteFile close and delete files of all shards
+func (sdb *ShardedDB) DeleteFile() error {
+	if sdb.name == "" {
+		return sdb.Close()
+	}
+	for i, db := range sdb.shards {
+		if db == nil {
+			continue
+		}
+		err := DeleteFile(shardName(sdb.name, i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeKeys merge sorted lists of keys in one sorted list
+// and apply limit/offset to result
+func mergeKeys(cmp Comparator, lists [][][]byte, limit, offset int, asc bool) [][]byte {
+	arr := make([][]byte, 0)
+	pos := make([]int, len(lists))
+	for limit == 0 || len(arr) < limit {
+		best := -1
+		for i, keys := range lists {
+			if pos[i] >= len(keys) {
+				continue
+			}
+			if best < 0 {
+				best = i
+				continue
+			}
+			c := cmp.Compare(keys[pos[i]], lists[best][pos[best]])
+			if (asc && c < 0) || (!asc && c > 0) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		if offset > 0 {
+			offset--
+		} else {
+			arr = append(arr, lists[best][pos[best]])
+		}
+		pos[best]++
+	}
+	return arr
+}
@@ -0,0 +1,94 @@
+package fudge
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSharded(t *testing.T) {
+	f := "test/sharded"
+	sdb, err := OpenSharded(f, &Config{Shards: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 22; i >= 1; i-- {
+		err = sdb.Set(fmt.Sprintf("%02d", i), i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cnt, _ := sdb.Count()
+	if cnt != 22 {
+		t.Error("count must be 22", cnt)
+	}
+	var v int
+	err = sdb.Get("07", &v)
+	if err != nil || v != 7 {
+		t.Error("not 7", v, err)
+	}
+
+	join := func(keys [][]byte) (s string) {
+		for _, k := range keys {
+			s += string(k)
+		}
+		return s
+	}
+	res, _ := sdb.Keys(nil, 0, 0, true)
+	if join(res) != "01020304050607080910111213141516171819202122" {
+		t.Error("not asc", join(res))
+	}
+	res, _ = sdb.Keys(nil, 2, 2, false)
+	if join(res) != "2019" {
+		t.Error("not off desc", join(res))
+	}
+	res, _ = sdb.Keys("10", 2, 2, true)
+	if join(res) != "1314" {
+		t.Error("not from asc", join(res))
+	}
+	res, _ = sdb.Keys("10", 2, 2, false)
+	if join(res) != "0706" {
+		t.Error("not from desc", join(res))
+	}
+	_, err = sdb.Keys("33", 2, 0, true)
+	if err != ErrKeyNotFound {
+		t.Error("must be ErrKeyNotFound", err)
+	}
+	res, _ = sdb.Keys("1*", 3, 1, true)
+	if join(res) != "111213" {
+		t.Error("not prefix", join(res))
+	}
+	_, err = sdb.KeysByPrefix([]byte("3"), 0, 0, true)
+	if err != ErrKeyNotFound {
+		t.Error("must be ErrKeyNotFound", err)
+	}
+
+	err = sdb.Delete("07")
+	if err != nil {
+		t.Error(err)
+	}
+	err = sdb.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = OpenSharded(f, &Config{Shards: 2})
+	if err != ErrShardCount {
+		t.Error("must be ErrShardCount", err)
+	}
+	sdb, err = OpenSharded(f, &Config{Shards: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	has, _ := sdb.Has("07")
+	if has {
+		t.Error("07 must be deleted")
+	}
+	cnt, _ = sdb.Count()
+	if cnt != 21 {
+		t.Error("count must be 21", cnt)
+	}
+	err = sdb.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+package fudge
+
+import (
+	"errors"
+	"maps"
+	"sync"
+)
+
+// ErrSnapshotReleased - snapshot used after Release
+var ErrSnapshotReleased = errors.New("error: snapshot released")
+
+// Snapshot is a read-only point-in-time view of db.
+// While any snapshot is not released, Set never overwrites values in place,
+// so snapshot keeps reading old values from the value file.
+type Snapshot struct {
+	db   *DB
+	view *DB
+}
+
+// Snapshot return consistent read-only view of db.
+// Release it when done, otherwise updated values will never reuse file space.
+func (db *DB) Snapshot() *Snapshot {
+	db.Lock()
+	defer db.Unlock()
+	return db.snapshot()
+}
+
+// snapshot return view of db without lock
+func (db *DB) snapshot() *Snapshot {
+	db.sort()
+	view := &DB{
+		RWMutex:     new(sync.RWMutex),
+		name:        db.name,
+		fv:          db.fv,
+		keys:        make([][]byte, len(db.keys)),
+		vals:        maps.Clone(db.vals),
+		storemode:   db.storemode,
+		keyEncoding: db.keyEncoding,
+		cmp:         db.cmp,
+		codec:       db.codec,
+	}
+	copy(view.keys, db.keys)
+	db.snapshots++
+	return &Snapshot{db: db, view: view}
+}
+
+// Release snapshot. Snapshot can't be used after release.
+func (s *Snapshot) Release() {
+	if s.view == nil {
+		return
+	}
+	s.db.Lock()
+	s.db.snapshots--
+	s.db.Unlock()
+	s.view = nil
+}
+
+// Get return value by key as it was at snapshot time
+// Return error if any.
+func (s *Snapshot) Get(key any, value any) error {
+	if s.view == nil {
+		return ErrSnapshotReleased
+	}
+	return s.view.Get(key, value)
+}
+
+// Has return true if key existed at snapshot time.
+// Return error if any.
+func (s *Snapshot) Has(key any) (bool, error) {
+	if s.view == nil {
+		return false, ErrSnapshotReleased
+	}
+	return s.view.Has(key)
+}
+
+// Count returns the number of items at snapshot time.
+func (s *Snapshot) Count() (int, error) {
+	if s.view == nil {
+		return -1, ErrSnapshotReleased
+	}
+	return s.view.Count()
+}
+
+// Keys return keys at snapshot time, same as DB.Keys
+func (s *Snapshot) Keys(from any, limit, offset int, asc bool) ([][]byte, error) {
+	if s.view == nil {
+		return nil, ErrSnapshotReleased
+	}
+	return s.view.Keys(from, limit, offset, asc)
+}
+
+// KeysByPrefix return keys with prefix at snapshot time, same as DB.KeysByPrefix
+func (s *Snapshot) KeysByPrefix(prefix []byte, limit, offset int, asc bool) ([][]byte, error) {
+	if s.view == nil {
+		return nil, ErrSnapshotReleased
+	}
+	return s.view.KeysByPrefix(prefix, limit, offset, asc)
+}
@@ -0,0 +1,63 @@
+package fudge
+
+import (
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, mode := range []int{0, 2} {
+		f := "test/snapshot"
+		db, err := Open(f, &Config{StoreMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= 10; i++ {
+			db.Set(i, i*10)
+		}
+		s := db.Snapshot()
+
+		db.Set(1, 1)
+		db.Set(5, 500000)
+		db.Set(11, 110)
+		db.Delete(2)
+
+		var v int
+		s.Get(1, &v)
+		if v != 10 {
+			t.Error("snapshot value must be 10", mode, v)
+		}
+		s.Get(5, &v)
+		if v != 50 {
+			t.Error("snapshot value must be 50", mode, v)
+		}
+		has, _ := s.Has(2)
+		if !has {
+			t.Error("deleted key must be in snapshot", mode)
+		}
+		has, _ = s.Has(11)
+		if has {
+			t.Error("new key must not be in snapshot", mode)
+		}
+		cnt, _ := s.Count()
+		if cnt != 10 {
+			t.Error("snapshot count must be 10", mode, cnt)
+		}
+		keys, _ := s.Keys(nil, 3, 3, true)
+		if len(keys) != 3 {
+			t.Error("snapshot keys must be 3", mode, len(keys))
+		}
+		db.Get(1, &v)
+		if v != 1 {
+			t.Error("db value must be 1", mode, v)
+		}
+
+		s.Release()
+		if _, err = s.Count(); err != ErrSnapshotReleased {
+			t.Error("must be ErrSnapshotReleased", mode, err)
+		}
+		err = db.DeleteFile()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package fudge
+
+import "iter"
+
+// Store is typed wrapper of db:
+//
+//	users := fudge.NewStore[string, User](db)
+//	users.Set("alice", User{Age: 30})
+//	u, err := users.Get("alice")
+//
+// Keys are converted with db key encoding and decoded with KeyFromBinary rules.
+// Keys of db which can't be decoded to K are skipped by Keys and All,
+// so keep keys of one type in db or under one Tuple prefix.
+type Store[K comparable, V any] struct {
+	db *DB
+}
+
+// NewStore return typed store over db
+func NewStore[K comparable, V any](db *DB) *Store[K, V] {
+	return &Store[K, V]{db: db}
+}
+
+// DB return underlying db
+func (s *Store[K, V]) DB() *DB {
+	return s.db
+}
+
+// Get return value by key
+// Return error if any.
+func (s *Store[K, V]) Get(key K) (V, error) {
+	var v V
+	err := s.db.Get(key, &v)
+	return v, err
+}
+
+// Set store key value
+func (s *Store[K, V]) Set(key K, value V) error {
+	return s.db.Set(key, value)
+}
+
+// Delete remove key
+// Returns error if key not found
+func (s *Store[K, V]) Delete(key K) error {
+	return s.db.Delete(key)
+}
+
+// Has return true if key exists.
+// Return error if any.
+func (s *Store[K, V]) Has(key K) (bool, error) {
+	return s.db.Has(key)
+}
+
+// Keys return iterator over keys in ascending order
+func (s *Store[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		it := s.db.NewIterator(nil)
+		defer it.Close()
+		for ok := it.First(); ok; ok = it.Next() {
+			var k K
+			if s.db.keyFromBinary(it.Key(), &k) != nil {
+				continue
+			}
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// All return iterator over key/value pairs in ascending order.
+// Value read or decode error stop iteration, use StoreIterator to check it with Err.
+func (s *Store[K, V]) All() iter.Seq2[K, V] {
+	return s.NewIterator(nil).All()
+}
+
+// StoreIterator walk typed key/value pairs of store
+type StoreIterator[K comparable, V any] struct {
+	it *Iterator
+}
+
+// NewIterator return iterator over keys of store bounded by opts
+func (s *Store[K, V]) NewIterator(opts *IteratorOptions) *StoreIterator[K, V] {
+	return &StoreIterator[K, V]{it: s.db.NewIterator(opts)}
+}
+
+// All return iterator over key/value pairs in ascending order and close it.
+// Keys which can't be decoded to K and keys deleted while iterating are skipped,
+// value read or decode error stop iteration and is returned by Err.
+func (si *StoreIterator[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		it := si.it
+		defer it.Close()
+		for ok := it.First(); ok; ok = it.Next() {
+			var k K
+			if it.db.keyFromBinary(it.Key(), &k) != nil {
+				continue
+			}
+			var v V
+			err := it.Value(&v)
+			if err == ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				it.err = err
+				return
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Err return error which stopped All if any
+func (si *StoreIterator[K, V]) Err() error {
+	return si.it.Err()
+}
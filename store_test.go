@@ -0,0 +1,87 @@
+package fudge
+
+import (
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	f := "test/store"
+	DeleteFile(f)
+	db, err := Open(f, &Config{KeyEncoding: KeyOrdered})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := NewStore[int, user](db)
+	for i := -2; i <= 2; i++ {
+		users.Set(i, user{"u", i})
+	}
+	u, err := users.Get(-1)
+	if err != nil || u.Age != -1 {
+		t.Error("bad user", u, err)
+	}
+	if _, err = users.Get(5); err != ErrKeyNotFound {
+		t.Error("must be ErrKeyNotFound", err)
+	}
+	users.Delete(0)
+	prev := -100
+	n := 0
+	for k, u := range users.All() {
+		if k <= prev || k != u.Age || k == 0 {
+			t.Error("bad pair", k, u)
+		}
+		prev = k
+		n++
+	}
+	if n != 4 {
+		t.Error("must be 4 users", n)
+	}
+	for k := range users.Keys() {
+		if k != -2 {
+			t.Error("first key must be -2", k)
+		}
+		break
+	}
+
+	names := NewStore[string, float64](db)
+	names.Set("pi", 3.14)
+	if v, _ := names.Get("pi"); v != 3.14 {
+		t.Error("must be 3.14", v)
+	}
+	// value of another type stop iteration with error
+	db.Set("x", "not a number")
+	it := names.NewIterator(&IteratorOptions{Prefix: []byte("x")})
+	for k, v := range it.All() {
+		t.Error("bad value must not be yielded", k, v)
+	}
+	if it.Err() == nil {
+		t.Error("decode error must be reported")
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+
+	db, err = Open("test/store_float", &Config{KeyEncoding: KeyOrdered})
+	if err != nil {
+		t.Fatal(err)
+	}
+	floats := NewStore[float64, bool](db)
+	for _, k := range []float64{1.5, -2.5, 0} {
+		floats.Set(k, true)
+	}
+	res := make([]float64, 0)
+	for k := range floats.Keys() {
+		res = append(res, k)
+	}
+	if len(res) != 3 || res[0] != -2.5 || res[2] != 1.5 {
+		t.Error("bad float keys", res)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,203 @@
+package fudge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrKeyFormat - key can't be decoded
+var ErrKeyFormat = errors.New("error: bad key format")
+
+// Tuple is composite key, elements are ordered one by one:
+//
+//	db.Set(fudge.Tuple{"tenant", 42, "order", time.Now()}, order)
+//
+// Packed tuple is prefix of any longer tuple with same first elements,
+// so prefix scan by leading elements works.
+// Supported elements: nil, []byte, string, bool, signed and unsigned integers,
+// floats and time.Time. Unpack return integers as int64 or uint64
+// and floats as float64.
+type Tuple []any
+
+// tuple element type codes, order of codes is order of types
+const (
+	tupleNil    = 0x00
+	tupleBytes  = 0x01
+	tupleString = 0x02
+	tupleInt    = 0x15
+	tupleUint   = 0x16
+	tupleFloat  = 0x21
+	tupleFalse  = 0x26
+	tupleTrue   = 0x27
+	tupleTime   = 0x33
+)
+
+// Pack return tuple in bytes
+func (t Tuple) Pack() ([]byte, error) {
+	b := make([]byte, 0, 16*len(t))
+	for _, e := range t {
+		switch e := e.(type) {
+		case nil:
+			b = append(b, tupleNil)
+		case []byte:
+			b = appendTupleBytes(append(b, tupleBytes), e)
+		case string:
+			b = appendTupleBytes(append(b, tupleString), []byte(e))
+		case bool:
+			if e {
+				b = append(b, tupleTrue)
+			} else {
+				b = append(b, tupleFalse)
+			}
+		case int:
+			b = appendTupleInt(b, int64(e))
+		case int8:
+			b = appendTupleInt(b, int64(e))
+		case int16:
+			b = appendTupleInt(b, int64(e))
+		case int32:
+			b = appendTupleInt(b, int64(e))
+		case int64:
+			b = appendTupleInt(b, e)
+		case uint:
+			b = binary.BigEndian.AppendUint64(append(b, tupleUint), uint64(e))
+		case uint8:
+			b = binary.BigEndian.AppendUint64(append(b, tupleUint), uint64(e))
+		case uint16:
+			b = binary.BigEndian.AppendUint64(append(b, tupleUint), uint64(e))
+		case uint32:
+			b = binary.BigEndian.AppendUint64(append(b, tupleUint), uint64(e))
+		case uint64:
+			b = binary.BigEndian.AppendUint64(append(b, tupleUint), e)
+		case float32:
+			b = append(b, tupleFloat)
+			b = append(b, FloatKeyToOrdered(binary.BigEndian.AppendUint64(nil, math.Float64bits(float64(e))))...)
+		case float64:
+			b = append(b, tupleFloat)
+			b = append(b, FloatKeyToOrdered(binary.BigEndian.AppendUint64(nil, math.Float64bits(e)))...)
+		case time.Time:
+			b = binary.BigEndian.AppendUint64(append(b, tupleTime), uint64(e.UnixNano())^(1<<63))
+		default:
+			return nil, fmt.Errorf("error: unsupported tuple element %T", e)
+		}
+	}
+	return b, nil
+}
+
+// Unpack decode packed tuple
+func (t *Tuple) Unpack(b []byte) error {
+	res := make(Tuple, 0)
+	for len(b) > 0 {
+		code := b[0]
+		b = b[1:]
+		switch code {
+		case tupleNil:
+			res = append(res, nil)
+		case tupleBytes, tupleString:
+			e, n, err := readTupleBytes(b)
+			if err != nil {
+				return err
+			}
+			b = b[n:]
+			if code == tupleString {
+				res = append(res, string(e))
+			} else {
+				res = append(res, e)
+			}
+		case tupleFalse:
+			res = append(res, false)
+		case tupleTrue:
+			res = append(res, true)
+		case tupleInt, tupleUint, tupleFloat, tupleTime:
+			if len(b) < 8 {
+				return ErrKeyFormat
+			}
+			u := binary.BigEndian.Uint64(b[:8])
+			switch code {
+			case tupleInt:
+				res = append(res, int64(u^(1<<63)))
+			case tupleUint:
+				res = append(res, u)
+			case tupleFloat:
+				if u&(1<<63) != 0 {
+					u ^= 1 << 63
+				} else {
+					u = ^u
+				}
+				res = append(res, math.Float64frombits(u))
+			case tupleTime:
+				res = append(res, time.Unix(0, int64(u^(1<<63))))
+			}
+			b = b[8:]
+		default:
+			return ErrKeyFormat
+		}
+	}
+	*t = res
+	return nil
+}
+
+func appendTupleInt(b []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, tupleInt), uint64(v)^(1<<63))
+}
+
+// appendTupleBytes append escaped bytes, 0x00 stored as 0x00 0xff, 0x00 is terminator
+func appendTupleBytes(b, e []byte) []byte {
+	for _, c := range e {
+		b = append(b, c)
+		if c == 0x00 {
+			b = append(b, 0xff)
+		}
+	}
+	return append(b, 0x00)
+}
+
+// readTupleBytes return unescaped bytes and length of escaped bytes with terminator
+func readTupleBytes(b []byte) ([]byte, int, error) {
+	res := make([]byte, 0)
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0x00 {
+			res = append(res, b[i])
+			continue
+		}
+		if i+1 < len(b) && b[i+1] == 0xff {
+			res = append(res, 0x00)
+			i++
+			continue
+		}
+		return res, i + 1, nil
+	}
+	return nil, 0, ErrKeyFormat
+}
+
+// KeyFromBinary decode key returned by Keys to v, v must be pointer.
+// It's reverse of KeyToBinary:
+//
+//	var t fudge.Tuple
+//	fudge.KeyFromBinary(keys[0], &t)
+func KeyFromBinary(b []byte, v any) error {
+	switch v := v.(type) {
+	case *Tuple:
+		return v.Unpack(b)
+	case *[]byte:
+		*v = bytes.Clone(b)
+		return nil
+	case *string:
+		*v = string(b)
+		return nil
+	case *int:
+		if len(b) != 8 {
+			return ErrKeyFormat
+		}
+		*v = int(binary.BigEndian.Uint64(b))
+		return nil
+	case *bool, *float32, *float64, *int8, *int16, *int32, *int64, *uint8, *uint16, *uint32, *uint64:
+		return binary.Read(bytes.NewReader(b), binary.BigEndian, v)
+	default:
+		return unmarshal(b, v)
+	}
+}
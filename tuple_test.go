@@ -0,0 +1,95 @@
+package fudge
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTuple(t *testing.T) {
+	ts := time.Unix(1700000000, 5)
+	tuples := []Tuple{
+		{"tenant", -5},
+		{"tenant", 7, "order", ts},
+		{"tenant", 7, "order", ts.Add(time.Second)},
+		{"tenant", 7, "order\x00x"},
+		{"tenant", 42},
+		{"tenant\x00"},
+		{"tenantA"},
+	}
+	var prev []byte
+	for _, tp := range tuples {
+		b, err := KeyToBinary(tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && bytes.Compare(prev, b) >= 0 {
+			t.Error("not ordered", tp)
+		}
+		prev = b
+	}
+
+	in := Tuple{nil, []byte{0, 1}, "s\x00", true, false, -42, uint8(3), 1.5, ts}
+	b, err := in.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Tuple
+	err = KeyFromBinary(b, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Tuple{nil, []byte{0, 1}, "s\x00", true, false, int64(-42), uint64(3), 1.5, ts}
+	if len(out) != len(want) {
+		t.Fatal("bad unpack", out)
+	}
+	for i := range want {
+		if tm, ok := want[i].(time.Time); ok {
+			if !tm.Equal(out[i].(time.Time)) {
+				t.Error("bad time", out[i])
+			}
+			continue
+		}
+		if !reflect.DeepEqual(out[i], want[i]) {
+			t.Errorf("element %d: %#v != %#v", i, out[i], want[i])
+		}
+	}
+	if _, err = (Tuple{struct{}{}}).Pack(); err == nil {
+		t.Error("struct element must fail")
+	}
+	if err = out.Unpack([]byte{tupleString, 'a'}); err != ErrKeyFormat {
+		t.Error("must be ErrKeyFormat", err)
+	}
+
+	f := "test/tuple"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tp := range tuples {
+		db.Set(tp, 1)
+	}
+	n := 0
+	for k := range db.Prefix(Tuple{"tenant", 7}) {
+		var tp Tuple
+		KeyFromBinary(k, &tp)
+		if tp[2] != "order" && tp[2] != "order\x00x" {
+			t.Error("bad element", tp)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Error("prefix must find 3 keys", n)
+	}
+	var i int
+	k, _ := KeyToBinary(-7)
+	KeyFromBinary(k, &i)
+	if i != -7 {
+		t.Error("int must be -7", i)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
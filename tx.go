@@ -0,0 +1,332 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"os"
+)
+
+var (
+	// ErrTxClosed - transaction used after Update/View returned
+	ErrTxClosed = errors.New("error: transaction closed")
+	// ErrTxNotWritable - write in read-only transaction
+	ErrTxNotWritable = errors.New("error: transaction not writable")
+)
+
+// Tx represent transaction.
+// Writes are buffered in the transaction and visible only to its own reads
+// until commit.
+type Tx struct {
+	db       *DB
+	writable bool
+	ops      []*txRecord
+	pending  map[string]*txRecord
+}
+
+// txRecord is one operation of transaction
+type txRecord struct {
+	t     uint8
+	key   []byte
+	val   []byte
+	codec uint8
+	cmd   *Cmd
+
+	flags   uint32
+	expire  uint32
+	expired bool // delete of expired key
+}
+
+// Update run fn in read-write transaction.
+// All Set/Delete in fn commit atomically if fn return nil,
+// and rollback if fn return error or panic.
+// Don't call db methods from fn - db is locked, use tx.
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	db.Lock()
+	defer db.Unlock()
+	tx := &Tx{db: db, writable: true, pending: make(map[string]*txRecord)}
+	defer tx.close()
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+	return db.commit(tx.ops)
+}
+
+// View run fn in read-only transaction.
+// Don't call db write methods from fn - db is locked, use tx.
+func (db *DB) View(fn func(tx *Tx) error) error {
+	db.RLock()
+	defer db.RUnlock()
+	tx := &Tx{db: db}
+	defer tx.close()
+	return fn(tx)
+}
+
+func (tx *Tx) close() {
+	tx.db = nil
+	tx.ops = nil
+	tx.pending = nil
+}
+
+// Set store key value in transaction
+func (tx *Tx) Set(key any, value any) error {
+	if tx.db == nil {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	k, err := tx.db.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	v, err := tx.db.valToBinary(value)
+	if err != nil {
+		return err
+	}
+	tx.put(recSet, k, bytes.Clone(v))
+	return nil
+}
+
+// Delete remove key in transaction
+// Returns error if key not found
+func (tx *Tx) Delete(key any) error {
+	if tx.db == nil {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	k, err := tx.db.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	if !tx.has(k) {
+		return ErrKeyNotFound
+	}
+	tx.put(recDelete, k, nil)
+	return nil
+}
+
+// put store operation, every key stored once with last operation.
+// Value is encoded with db codec.
+func (tx *Tx) put(t uint8, k, v []byte) *txRecord {
+	if r, ok := tx.pending[string(k)]; ok {
+		r.t = t
+		r.val = v
+		r.codec = tx.db.codec.ID()
+		r.flags, r.expire = 0, 0
+		return r
+	}
+	r := &txRecord{t: t, key: bytes.Clone(k), val: v, codec: tx.db.codec.ID()}
+	tx.pending[string(k)] = r
+	tx.ops = append(tx.ops, r)
+	return r
+}
+
+// Get return value by key, including not committed writes of transaction
+// Return error if any.
+func (tx *Tx) Get(key any, value any) error {
+	if tx.db == nil {
+		return ErrTxClosed
+	}
+	k, err := tx.db.keyToBinary(key)
+	if err != nil {
+		return err
+	}
+	if r, ok := tx.pending[string(k)]; ok {
+		if r.t == recDelete {
+			return ErrKeyNotFound
+		}
+		return tx.db.unmarshal(r.codec, bytes.Clone(r.val), value)
+	}
+	return tx.db.get(k, value)
+}
+
+// Has return true if key exists, including not committed writes of transaction
+// Return error if any.
+func (tx *Tx) Has(key any) (bool, error) {
+	if tx.db == nil {
+		return false, ErrTxClosed
+	}
+	k, err := tx.db.keyToBinary(key)
+	if err != nil {
+		return false, err
+	}
+	return tx.has(k), nil
+}
+
+func (tx *Tx) has(k []byte) bool {
+	if r, ok := tx.pending[string(k)]; ok {
+		return r.t == recSet
+	}
+	_, ok := tx.db.live(k)
+	return ok
+}
+
+// commit apply operations atomically, db must be locked.
+// On disk operations are framed with begin/commit records,
+// frame without commit record is dropped on open.
+func (db *DB) commit(recs []*txRecord) error {
+	ops := make([]*txRecord, 0, len(recs))
+	for _, r := range recs {
+		if r.t == recSet || r.t == recDelete || r.t == recKeyEncoding {
+			if err := db.writable(); err != nil {
+				return err
+			}
+		}
+		if _, ok := db.vals[string(r.key)]; !ok && r.t == recDelete {
+			continue
+		}
+		ops = append(ops, r)
+	}
+	idx, err := db.indexOps(ops)
+	if err != nil {
+		return err
+	}
+	ops = append(ops, idx...)
+	if len(ops) == 0 {
+		return nil
+	}
+	for _, r := range ops {
+		if r.t == recIndex || r.t == recUnindex {
+			r.cmd = &Cmd{ver: recVersion}
+			continue
+		}
+		if r.t == recKeyEncoding {
+			// cmd is set by Rekey
+			continue
+		}
+		r.cmd = &Cmd{Size: uint32(len(r.val)), Rev: db.nextRev(), ver: recVersion, codec: r.codec, flags: r.flags, expire: r.expire}
+		if db.storemode == 2 {
+			r.cmd.Val = r.val
+		}
+	}
+	if db.storemode != 2 {
+		err = db.writeFrame(ops)
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range ops {
+		db.applyRecord(r.t, r.key, r.cmd)
+	}
+	for _, r := range ops {
+		switch r.t {
+		case recSet:
+			db.notify(EventSet, r.key, r.val, r.cmd.Rev)
+		case recDelete:
+			e := EventDelete
+			if r.expired {
+				e = EventExpire
+			}
+			db.notify(e, r.key, nil, r.cmd.Rev)
+		default:
+			continue
+		}
+		err = db.logChange(r.t, r.key, r.val, r.codec, r.flags, r.expire, r.cmd.Rev)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFrame write values at the end of value file
+// and all index records in one write.
+// Commit record keep crc32 of values in flags, so values torn by crash
+// are found on open and files are synced in parallel without barrier.
+func (db *DB) writeFrame(ops []*txRecord) error {
+	sum := crc32.NewIEEE()
+	for _, r := range ops {
+		if r.t != recSet {
+			continue
+		}
+		seek, _, err := writeAtPos(db.fv, r.val, -1)
+		if err != nil {
+			return err
+		}
+		r.cmd.Seek = uint32(seek)
+		sum.Write(r.val)
+	}
+	buf := new(bytes.Buffer)
+	offsets := make([]int, len(ops))
+	encodeKey(buf, recBegin, 0, uint32(len(ops)), 0, 0, 0, 0, nil)
+	for i, r := range ops {
+		offsets[i] = buf.Len()
+		t, k := db.rec(r.t, r.key)
+		encodeKey(buf, t, r.cmd.Seek, r.cmd.Size, r.cmd.Rev, r.cmd.codec, r.cmd.flags, r.cmd.expire, k)
+	}
+	encodeKey(buf, recCommit, 0, uint32(len(ops)), 0, 0, sum.Sum32(), 0, nil)
+
+	seek, _, err := writeAtPos(db.fk, buf.Bytes(), -1)
+	if err == nil {
+		err = syncFiles(db.fv, db.fk)
+	}
+	if err != nil {
+		_ = db.fk.Truncate(seek)
+		return err
+	}
+	for i, r := range ops {
+		r.cmd.KeySeek = uint32(seek) + uint32(offsets[i])
+	}
+	return nil
+}
+
+// Rekey replace every key with conv(key) and store key encoding enc in one transaction.
+// Use it for migration between key encodings: open db with its current encoding,
+// rekey and open with new encoding next time, for example from KeyBinary to KeyOrdered
+// for db with only int keys (conv is applied to every key, use own conv for mixed keys):
+//
+//	db, err := fudge.Open("numbers", nil)
+//	err = db.Rekey(fudge.KeyOrdered, fudge.IntKeyToOrdered)
+//
+// Keys of buckets are not converted, Rekey of bucket return ErrKeyEncoding
+// if enc is not encoding of db.
+func (db *DB) Rekey(enc int, conv func(key []byte) []byte) error {
+	if db.parent != nil && enc != db.keyEncoding {
+		return ErrKeyEncoding
+	}
+	return db.Update(func(tx *Tx) error {
+		db.sort()
+		moved := make([]*txRecord, 0)
+		for _, k := range db.keys {
+			nk := conv(k)
+			if bytes.Equal(nk, k) {
+				continue
+			}
+			cmd := db.vals[string(k)]
+			v, err := db.readVal(cmd)
+			if err != nil {
+				return err
+			}
+			moved = append(moved, &txRecord{key: nk, val: v, codec: cmd.codec, flags: cmd.flags, expire: cmd.expire})
+			tx.put(recDelete, k, nil)
+		}
+		// new key may be equal to old key of another moved record
+		for _, r := range moved {
+			nr := tx.put(recSet, r.key, r.val)
+			nr.codec, nr.flags, nr.expire = r.codec, r.flags, r.expire
+		}
+		if db.parent == nil && enc != db.keyEncoding {
+			tx.ops = append(tx.ops, &txRecord{t: recKeyEncoding, cmd: &Cmd{Size: uint32(enc), ver: recVersion}})
+		}
+		return nil
+	})
+}
+
+// syncFiles fsync files in parallel, return first error
+func syncFiles(files ...*os.File) error {
+	errs := make(chan error, len(files))
+	for _, f := range files {
+		go func() { errs <- f.Sync() }()
+	}
+	var err error
+	for range files {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package fudge
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	f := "test/tx"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set(1, 1)
+	db.Set(2, 2)
+
+	err = db.Update(func(tx *Tx) error {
+		tx.Set(1, 10)
+		tx.Set(3, 30)
+		var v int
+		tx.Get(3, &v)
+		if v != 30 {
+			t.Error("tx must see own write", v)
+		}
+		if err := tx.Delete(2); err != nil {
+			t.Error(err)
+		}
+		if has, _ := tx.Has(2); has {
+			t.Error("tx must see own delete")
+		}
+		if err := tx.Delete(4); err != ErrKeyNotFound {
+			t.Error("must be ErrKeyNotFound", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errRollback := errors.New("rollback")
+	err = db.Update(func(tx *Tx) error {
+		tx.Set(1, 100)
+		tx.Delete(3)
+		return errRollback
+	})
+	if err != errRollback {
+		t.Error("must be errRollback", err)
+	}
+
+	check := func() {
+		var v int
+		db.Get(1, &v)
+		if v != 10 {
+			t.Error("1 must be 10", v)
+		}
+		db.Get(3, &v)
+		if v != 30 {
+			t.Error("3 must be 30", v)
+		}
+		if has, _ := db.Has(2); has {
+			t.Error("2 must be deleted")
+		}
+		if cnt, _ := db.Count(); cnt != 2 {
+			t.Error("count must be 2", cnt)
+		}
+	}
+	check()
+	err = db.View(func(tx *Tx) error {
+		return tx.Set(5, 5)
+	})
+	if err != ErrTxNotWritable {
+		t.Error("must be ErrTxNotWritable", err)
+	}
+
+	// update in place after transaction
+	db.Set(3, 30)
+	db.Close()
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check()
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateCrash(t *testing.T) {
+	f := "test/txcrash"
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Set(1, 1)
+	db.Close()
+
+	// transaction without commit record and torn record at the end
+	buf := new(bytes.Buffer)
+	encodeKey(buf, recBegin, 0, 2, 0, 0, 0, 0, nil)
+	encodeKey(buf, recSet, 0, 1, 10, 0, 0, 0, []byte("2"))
+	encodeKey(buf, recDelete, 0, 0, 11, 0, 0, 0, []byte{0, 0, 0, 0, 0, 0, 0, 1})
+	buf.Write([]byte{0, 0, 0})
+	fk, err := os.OpenFile(f+".idx", os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fk.Write(buf.Bytes())
+	fk.Close()
+
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt, _ := db.Count(); cnt != 1 {
+		t.Error("count must be 1", cnt)
+	}
+	if has, _ := db.Has(1); !has {
+		t.Error("1 must not be deleted")
+	}
+	db.Update(func(tx *Tx) error {
+		return tx.Set(2, 2)
+	})
+	db.Close()
+
+	db, err = Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	db.Get(2, &v)
+	if v != 2 {
+		t.Error("2 must be 2", v)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,87 @@
+package fudge
+
+import (
+	"bytes"
+	"context"
+)
+
+// EventType is type of change
+type EventType uint8
+
+// Event types
+const (
+	EventSet        EventType = iota // key stored, Value is new value
+	EventDelete                      // key deleted
+	EventExpire                      // key expired
+	EventDropBucket                  // bucket deleted, only in change log
+)
+
+// WatchBuffer is size of watcher channel
+var WatchBuffer = 256
+
+// Event is change of key sent to watchers after commit
+type Event struct {
+	Type  EventType
+	Key   []byte
+	Value []byte // new value in binary form for EventSet
+	Rev   uint64 // revision of change
+}
+
+type watcher struct {
+	prefix []byte
+	ch     chan Event
+	done   chan struct{} // closed by unwatch
+}
+
+// Watch return channel of changes of keys with prefix, nil prefix watch all keys.
+// Events are sent after commit of Set, Delete, transactions and batches.
+// Channel is closed when ctx is done, db is closed or bucket of db is deleted.
+// Slow consumer policy: send never block writes, if channel buffer (WatchBuffer)
+// is full the channel is closed and events are lost, watch again and reread keys.
+func (db *DB) Watch(ctx context.Context, prefix []byte) <-chan Event {
+	w := &watcher{prefix: bytes.Clone(prefix), ch: make(chan Event, WatchBuffer), done: make(chan struct{})}
+	db.Lock()
+	if db.watchers == nil {
+		db.watchers = make(map[*watcher]struct{})
+	}
+	db.watchers[w] = struct{}{}
+	db.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			db.Lock()
+			db.unwatch(w)
+			db.Unlock()
+		case <-w.done:
+		}
+	}()
+	return w.ch
+}
+
+// notify send event to watchers, db must be locked
+func (db *DB) notify(t EventType, k, v []byte, rev uint64) {
+	if len(db.watchers) == 0 {
+		return
+	}
+	e := Event{Type: t, Key: bytes.Clone(k), Value: bytes.Clone(v), Rev: rev}
+	for w := range db.watchers {
+		if !bytes.HasPrefix(k, w.prefix) {
+			continue
+		}
+		select {
+		case w.ch <- e:
+		default:
+			// slow consumer
+			db.unwatch(w)
+		}
+	}
+}
+
+// unwatch remove watcher and close its channel, db must be locked
+func (db *DB) unwatch(w *watcher) {
+	if _, ok := db.watchers[w]; ok {
+		delete(db.watchers, w)
+		close(w.ch)
+		close(w.done)
+	}
+}
@@ -0,0 +1,91 @@
+package fudge
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	f := "test/watch"
+	DeleteFile(f)
+	db, err := Open(f, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := db.Watch(ctx, []byte("user:"))
+	db.Set("user:1", []byte("alice"))
+	db.Set("order:1", 1)
+	db.Delete("user:1")
+	b := new(Batch)
+	b.Put("user:2", []byte("bob"))
+	b.Put("user:3", []byte("carol"))
+	db.Write(b)
+
+	e := <-ch
+	if e.Type != EventSet || string(e.Key) != "user:1" || string(e.Value) != "alice" {
+		t.Error("bad set event", e)
+	}
+	e = <-ch
+	if e.Type != EventDelete || string(e.Key) != "user:1" || e.Rev != db.Revision()-2 {
+		t.Error("bad delete event", e)
+	}
+	e = <-ch
+	if string(e.Key) != "user:2" {
+		t.Error("bad batch event", e)
+	}
+	<-ch
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("channel must be closed")
+	}
+
+	// slow consumer
+	ch = db.Watch(context.Background(), nil)
+	for i := 0; i <= WatchBuffer; i++ {
+		db.Set(i, i)
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != WatchBuffer {
+		t.Error("slow watcher must be closed after full buffer", n)
+	}
+	err = db.DeleteFile()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWatchClose(t *testing.T) {
+	f := "test/watch_close"
+	DeleteFile(f)
+	db, err := Open(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := runtime.NumGoroutine()
+	users, _ := db.Bucket("users")
+	ch := users.Watch(context.Background(), nil)
+	all := db.Watch(context.Background(), nil)
+	// slow consumer
+	slow := db.Watch(context.Background(), nil)
+	for i := 0; i <= WatchBuffer; i++ {
+		db.Set(i, i)
+		<-all
+	}
+	for range slow {
+	}
+
+	db.DeleteBucket("users")
+	if _, ok := <-ch; ok {
+		t.Error("channel of deleted bucket must be closed")
+	}
+	db.DeleteFile()
+	if _, ok := <-all; ok {
+		t.Error("channel must be closed with db")
+	}
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= n })
+}